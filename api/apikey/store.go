@@ -0,0 +1,184 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/4lerman/e_com/common/tracing"
+	"github.com/lib/pq"
+)
+
+const keyColumns = "id, name, prefix, scopes, expiresAt, lastUsedAt, revokedAt, createdAt"
+
+// touchInterval is how stale a key's last-used time may get, so that busy
+// keys do not cost a write per request.
+const touchInterval = time.Minute
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db,
+	}
+}
+
+func (s *Store) CreateKey(ctx context.Context, payload CreateKeyPayload) (*IssuedKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyStore.CreateKey")
+	defer span.End()
+
+	secret, prefix, hash, err := generate()
+	if err != nil {
+		return nil, err
+	}
+
+	// expiresAt has no time zone, it is kept in UTC
+	var expiresAt *time.Time
+	if payload.ExpiresAt != nil {
+		utc := payload.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	row := s.db.QueryRowContext(ctx, "INSERT INTO api_keys (name, prefix, keyHash, scopes, expiresAt) "+
+		"VALUES ($1, $2, $3, $4, $5) RETURNING "+keyColumns,
+		payload.Name, prefix, hash, pq.Array(payload.Scopes), expiresAt)
+
+	key, err := scanRowIntoKey(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return &IssuedKey{Key: *key, Secret: secret}, nil
+}
+
+func (s *Store) ListKeys(ctx context.Context) ([]Key, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyStore.ListKeys")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+keyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []Key{}
+	for rows.Next() {
+		key, err := scanRowIntoKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, *key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RotateKey replaces the key with a new one that keeps its name, scopes and
+// expiry. The old key stops working at once.
+func (s *Store) RotateKey(ctx context.Context, keyId int) (*IssuedKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyStore.RotateKey")
+	defer span.End()
+
+	secret, prefix, hash, err := generate()
+	if err != nil {
+		return nil, err
+	}
+
+	row := s.db.QueryRowContext(ctx, "UPDATE api_keys SET prefix = $1, keyHash = $2, lastUsedAt = NULL "+
+		"WHERE id = $3 AND revokedAt IS NULL RETURNING "+keyColumns,
+		prefix, hash, keyId)
+
+	key, err := scanRowIntoKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+
+	return &IssuedKey{Key: *key, Secret: secret}, nil
+}
+
+// RevokeKey disables the key for good. Revoked keys stay listed.
+func (s *Store) RevokeKey(ctx context.Context, keyId int) error {
+	ctx, span := tracing.Start(ctx, "APIKeyStore.RevokeKey")
+	defer span.End()
+
+	res, err := s.db.ExecContext(ctx, "UPDATE api_keys SET revokedAt = CURRENT_TIMESTAMP WHERE id = $1 AND revokedAt IS NULL", keyId)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Verify returns the key a client presented if it exists, is not revoked
+// and has not expired, and records that it was used.
+func (s *Store) Verify(ctx context.Context, secret string) (*Key, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyStore.Verify")
+	defer span.End()
+
+	row := s.db.QueryRowContext(ctx, "SELECT "+keyColumns+" FROM api_keys WHERE keyHash = $1", hashKey(secret))
+
+	key, err := scanRowIntoKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := key.check(now); err != nil {
+		return nil, err
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > touchInterval {
+		go s.touch(key.ID)
+	}
+
+	return key, nil
+}
+
+// touch records that the key was just used. It runs after the request
+// was let through, so failures are only logged.
+func (s *Store) touch(keyId int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, "UPDATE api_keys SET lastUsedAt = CURRENT_TIMESTAMP WHERE id = $1", keyId); err != nil {
+		slog.Warn("failed to record API key use", "key_id", keyId, "error", err)
+	}
+}
+
+func scanRowIntoKey(row interface{ Scan(...any) error }) (*Key, error) {
+	key := new(Key)
+
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		pq.Array(&key.Scopes),
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
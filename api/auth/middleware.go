@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/4lerman/e_com/api/apikey"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+type contextKey struct{}
+
+// KeyVerifier resolves the API key a client presented.
+type KeyVerifier interface {
+	Verify(ctx context.Context, secret string) (*apikey.Key, error)
+}
+
+var keys KeyVerifier
+
+// UseKeys lets Authenticate accept API keys, verified by v.
+func UseKeys(v KeyVerifier) {
+	keys = v
+}
+
+// SessionChecker tells whether the session an access token was issued in
+// is still active.
+type SessionChecker interface {
+	Active(ctx context.Context, id int) (bool, error)
+}
+
+var sessions SessionChecker
+
+// UseSessions makes Authenticate reject access tokens of sessions that
+// were revoked or have expired, as told by c.
+func UseSessions(c SessionChecker) {
+	sessions = c
+}
+
+// StripIdentity removes identity headers sent by clients, so only ones set by
+// Authenticate ever reach the services.
+func StripIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity.Strip(r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// QueryToken accepts the access token in the access_token query parameter,
+// for clients that cannot set headers, such as browser EventSource and
+// WebSocket clients.
+func QueryToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if token := query.Get("access_token"); token != "" {
+			if r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			// keep the token out of anything that logs or forwards the URL
+			query.Del("access_token")
+			r.URL.RawQuery = query.Encode()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Authenticate requires a valid bearer access token that satisfies policy,
+// or an API key with the scope the request needs, and passes the verified
+// identity upstream in trusted headers. Routes with no scopes do not accept
+// API keys.
+func Authenticate(policy Policy, scopes Scopes, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret := r.Header.Get(apikey.Header); secret != "" {
+			authenticateKey(w, r, secret, scopes, next)
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("missing bearer token"))
+			return
+		}
+
+		id, claims, err := ParseToken(token, AccessToken)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			utils.WriteError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		if sessions != nil {
+			active, err := sessions.Active(r.Context(), claims.SessionID)
+			if err != nil {
+				utils.WriteError(w, http.StatusServiceUnavailable, fmt.Errorf("failed to check session: %w", err))
+				return
+			}
+
+			if !active {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("session has ended"))
+				return
+			}
+		}
+
+		if !policy(id, r) {
+			utils.WriteError(w, http.StatusForbidden, fmt.Errorf("%s role is not allowed to %s %s", id.Role, r.Method, r.URL.Path))
+			return
+		}
+
+		identity.Set(r, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// authenticateKey lets an API key client through as the service identity
+// if its key grants the scope of the request.
+func authenticateKey(w http.ResponseWriter, r *http.Request, secret string, scopes Scopes, next http.Handler) {
+	if scopes == nil || keys == nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("API keys are not accepted on %s", r.URL.Path))
+		return
+	}
+
+	key, err := keys.Verify(r.Context(), secret)
+	switch {
+	case errors.Is(err, apikey.ErrInvalidKey), errors.Is(err, apikey.ErrRevoked), errors.Is(err, apikey.ErrExpired):
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	case err != nil:
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to verify API key: %w", err))
+		return
+	}
+
+	scope := scopes(r)
+	if !key.Allows(scope) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("API key %s lacks the %s scope", key.Prefix, scope))
+		return
+	}
+
+	id := identity.Identity{Role: identity.Service, KeyID: key.ID}
+	identity.Set(r, id)
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+}
+
+// FromContext returns the identity verified by Authenticate.
+func FromContext(ctx context.Context) (identity.Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(identity.Identity)
+	return id, ok
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
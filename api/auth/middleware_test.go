@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/4lerman/e_com/api/apikey"
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/identity"
+)
+
+func TestAuthenticate(t *testing.T) {
+	configs.Envs.JWT_Secret = "test-secret-that-is-long-enough-for-hs256"
+
+	caller := identity.Identity{UserID: 7, Role: identity.Client}
+	token := func(t *testing.T, tokenType string, ttl time.Duration) string {
+		t.Helper()
+		signed, err := sign(caller, 3, tokenType, ttl)
+		if err != nil {
+			t.Fatalf("sign() error = %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		policy Policy
+		want   int
+	}{
+		{
+			name:   "missing token",
+			header: http.Header{},
+			policy: Authenticated,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid token",
+			header: http.Header{"Authorization": {"Bearer not-a-token"}},
+			policy: Authenticated,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "expired token",
+			header: http.Header{"Authorization": {"Bearer " + token(t, AccessToken, -time.Minute)}},
+			policy: Authenticated,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "token of another type",
+			header: http.Header{"Authorization": {"Bearer " + token(t, "refresh", time.Minute)}},
+			policy: Authenticated,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "denied by policy",
+			header: http.Header{"Authorization": {"Bearer " + token(t, AccessToken, time.Minute)}},
+			policy: Admin,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "allowed by policy",
+			header: http.Header{"Authorization": {"Bearer " + token(t, AccessToken, time.Minute)}},
+			policy: Authenticated,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "API key on a route without scopes",
+			header: http.Header{apikey.Header: {"some-key"}},
+			policy: Authenticated,
+			want:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got identity.Identity
+			var forwarded http.Header
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = FromContext(r.Context())
+				forwarded = r.Header
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			r.Header = tt.header
+			w := httptest.NewRecorder()
+
+			Authenticate(tt.policy, nil, next).ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			if got.UserID != caller.UserID || got.Role != caller.Role || got.SessionID != 3 {
+				t.Errorf("FromContext() = %+v, want user %d, role %s, session 3", got, caller.UserID, caller.Role)
+			}
+			if id := forwarded.Get(identity.UserIDHeader); id != strconv.Itoa(caller.UserID) {
+				t.Errorf("%s = %q, want %d", identity.UserIDHeader, id, caller.UserID)
+			}
+		})
+	}
+}
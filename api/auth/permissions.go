@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+)
+
+// PermissionChecker answers whether a role holds a permission.
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+}
+
+var permissions PermissionChecker
+
+// UsePermissions lets Permitted and Grant consult c about the permissions
+// of roles.
+func UsePermissions(c PermissionChecker) {
+	permissions = c
+}
+
+// Permissions names the permission a request needs.
+type Permissions func(*http.Request) string
+
+// CRUD requires resource:read for safe methods, resource:create for POST,
+// resource:delete for DELETE and resource:update for the others.
+func CRUD(resource string) Permissions {
+	return func(r *http.Request) string {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			return resource + ":read"
+		case http.MethodPost:
+			return resource + ":create"
+		case http.MethodDelete:
+			return resource + ":delete"
+		default:
+			return resource + ":update"
+		}
+	}
+}
+
+// Matching requires the permissions of match for requests whose path
+// matches pattern, and those of other for the rest.
+func Matching(pattern *regexp.Regexp, match, other Permissions) Permissions {
+	return func(r *http.Request) string {
+		if pattern.MatchString(r.URL.Path) {
+			return match(r)
+		}
+
+		return other(r)
+	}
+}
+
+// Permitted allows callers whose role holds the permission the request
+// needs.
+func Permitted(p Permissions) Policy {
+	return func(id identity.Identity, r *http.Request) bool {
+		return holds(id, r, p(r))
+	}
+}
+
+// Granted returns the identity with the permission the request needs, if
+// the caller's role holds it, so the services let the caller act on every
+// record. Admins and API key clients do so anyway.
+func Granted(id identity.Identity, r *http.Request, p Permissions) identity.Identity {
+	if id.IsAdmin() || id.IsService() {
+		return id
+	}
+
+	if permission := p(r); holds(id, r, permission) {
+		id.Permission = permission
+	}
+
+	return id
+}
+
+// Grant passes the identity verified by Authenticate upstream with the
+// permission the request needs, if the caller's role holds it.
+func Grant(p Permissions, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := FromContext(r.Context()); ok {
+			id = Granted(id, r, p)
+			identity.Set(r, id)
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, id))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// holds asks the checker about the caller's role. Failures deny.
+func holds(id identity.Identity, r *http.Request, permission string) bool {
+	if permissions == nil || id.Role == "" {
+		return false
+	}
+
+	ok, err := permissions.HasPermission(r.Context(), id.Role, permission)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("permission check failed", "role", id.Role, "permission", permission, "error", err)
+		return false
+	}
+
+	return ok
+}
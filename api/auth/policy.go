@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/4lerman/e_com/common/identity"
+)
+
+// Policy decides whether an authenticated identity may perform a request.
+type Policy func(identity.Identity, *http.Request) bool
+
+func Admin(id identity.Identity, r *http.Request) bool {
+	return id.IsAdmin()
+}
+
+// Authenticated allows any caller with a valid token.
+func Authenticated(id identity.Identity, r *http.Request) bool {
+	return true
+}
+
+// ReadOnly allows safe methods only.
+func ReadOnly(id identity.Identity, r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+func Methods(methods ...string) Policy {
+	return func(id identity.Identity, r *http.Request) bool {
+		for _, method := range methods {
+			if r.Method == method {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// OwnUser allows reading, updating and erasing prefix/{id}, changing the
+// password at prefix/{id}/password, exporting personal data from
+// prefix/{id}/export, managing the address book under
+// prefix/{id}/addresses and listing and revoking sessions under
+// prefix/{id}/sessions, when id is the caller's own user id.
+func OwnUser(prefix string) Policy {
+	return func(id identity.Identity, r *http.Request) bool {
+		path := strings.TrimPrefix(r.URL.Path, prefix+"/")
+
+		if user, rest, ok := strings.Cut(path, "/"); ok {
+			switch {
+			case rest == "password" && r.Method == http.MethodPut:
+			case rest == "export" && r.Method == http.MethodGet:
+			case rest == "addresses" || strings.HasPrefix(rest, "addresses/"):
+			case (rest == "sessions" || strings.HasPrefix(rest, "sessions/")) &&
+				(r.Method == http.MethodGet || r.Method == http.MethodDelete):
+			default:
+				return false
+			}
+			path = user
+		} else if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			return false
+		}
+
+		userId, err := strconv.Atoi(path)
+		return err == nil && userId == id.UserID
+	}
+}
+
+func Any(policies ...Policy) Policy {
+	return func(id identity.Identity, r *http.Request) bool {
+		for _, policy := range policies {
+			if policy(id, r) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Scopes names the API key scope a request needs.
+type Scopes func(*http.Request) string
+
+// ReadWrite requires resource:read for safe methods and resource:write for
+// the others.
+func ReadWrite(resource string) Scopes {
+	return func(r *http.Request) string {
+		if ReadOnly(identity.Identity{}, r) {
+			return resource + ":read"
+		}
+
+		return resource + ":write"
+	}
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/4lerman/e_com/common/identity"
+)
+
+func TestOwnUser(t *testing.T) {
+	policy := OwnUser("/api/v1/users")
+	caller := identity.Identity{UserID: 7, Role: identity.Client}
+
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{http.MethodGet, "/api/v1/users/7", true},
+		{http.MethodPut, "/api/v1/users/7", true},
+		{http.MethodDelete, "/api/v1/users/7", true},
+		{http.MethodPost, "/api/v1/users/7", false},
+		{http.MethodGet, "/api/v1/users/8", false},
+		{http.MethodGet, "/api/v1/users/me", false},
+		{http.MethodPut, "/api/v1/users/7/password", true},
+		{http.MethodGet, "/api/v1/users/7/password", false},
+		{http.MethodPut, "/api/v1/users/8/password", false},
+		{http.MethodGet, "/api/v1/users/7/export", true},
+		{http.MethodPost, "/api/v1/users/7/export", false},
+		{http.MethodGet, "/api/v1/users/7/addresses", true},
+		{http.MethodPost, "/api/v1/users/7/addresses", true},
+		{http.MethodDelete, "/api/v1/users/7/addresses/3", true},
+		{http.MethodGet, "/api/v1/users/8/addresses", false},
+		{http.MethodGet, "/api/v1/users/7/sessions", true},
+		{http.MethodDelete, "/api/v1/users/7/sessions/3", true},
+		{http.MethodPost, "/api/v1/users/7/sessions", false},
+		{http.MethodDelete, "/api/v1/users/8/sessions", false},
+		{http.MethodGet, "/api/v1/users/7/roles", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := policy(caller, r); got != tt.want {
+				t.Errorf("OwnUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AccessToken is the type of the tokens the gateway signs. Refresh tokens
+// are opaque and belong to the user service's sessions.
+const AccessToken = "access"
+
+type Claims struct {
+	Role      string `json:"role"`
+	Type      string `json:"typ"`
+	SessionID int    `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	SessionID    int    `json:"session_id"`
+}
+
+var errInvalidToken = errors.New("invalid or expired token")
+
+// IssueTokens signs an access token for the identity in the session and
+// pairs it with the session's refresh token.
+func IssueTokens(id identity.Identity, sessionId int, refreshToken string) (*TokenPair, error) {
+	access, err := sign(id, sessionId, AccessToken, time.Duration(configs.Envs.Access_Token_TTL)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    configs.Envs.Access_Token_TTL,
+		SessionID:    sessionId,
+	}, nil
+}
+
+// ParseToken verifies the signature, expiry and type of a token and returns
+// the identity it was issued for.
+func ParseToken(token string, tokenType string) (identity.Identity, *Claims, error) {
+	claims := new(Claims)
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		return []byte(configs.Envs.JWT_Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+
+	if err != nil || claims.Type != tokenType {
+		return identity.Identity{}, nil, errInvalidToken
+	}
+
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return identity.Identity{}, nil, errInvalidToken
+	}
+
+	return identity.Identity{UserID: userId, Role: claims.Role, SessionID: claims.SessionID}, claims, nil
+}
+
+func sign(id identity.Identity, sessionId int, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Role:      id.Role,
+		Type:      tokenType,
+		SessionID: sessionId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(id.UserID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(configs.Envs.JWT_Secret))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Store is a key-value store with expiring values and counters. The
+// in-memory store is local to one gateway, the redis store is shared by
+// every replica, so a write through any of them invalidates all caches.
+type Store interface {
+	// Get returns the value of key, or nil if it is missing or expired.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Incr(ctx context.Context, key string) error
+}
+
+// Rule caches GET responses of the paths matching Path for TTL.
+type Rule struct {
+	Path *regexp.Regexp
+	TTL  time.Duration
+}
+
+// Entry is a cached response.
+type Entry struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// NewStore returns the backend selected by CACHE_BACKEND, or nil when
+// response caching is off.
+func NewStore() (Store, error) {
+	switch configs.Envs.Cache_Backend {
+	case "none", "":
+		return nil, nil
+	case "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		opts, err := redis.ParseURL(configs.Envs.Redis_Url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+
+		return NewRedisStore(redis.NewClient(opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", configs.Envs.Cache_Backend)
+	}
+}
+
+// Every group has a generation counter that is part of its entry keys.
+// Invalidating a group bumps it, so older entries are never read again and
+// simply expire.
+func generationKey(group string) string {
+	return "cache:" + group + ":generation"
+}
+
+func generation(ctx context.Context, store Store, group string) (string, error) {
+	value, err := store.Get(ctx, generationKey(group))
+	if err != nil {
+		return "", err
+	}
+
+	if value == nil {
+		return "0", nil
+	}
+
+	return string(value), nil
+}
+
+func entryKey(group, generation string, r *http.Request) string {
+	return "cache:" + group + ":" + generation + ":" + r.URL.RequestURI()
+}
+
+func match(rules []Rule, r *http.Request) (time.Duration, bool) {
+	for _, rule := range rules {
+		if rule.Path.MatchString(r.URL.Path) {
+			return rule.TTL, rule.TTL > 0
+		}
+	}
+
+	return 0, false
+}
+
+func age(entry *Entry) string {
+	return strconv.Itoa(int(time.Since(entry.StoredAt).Seconds()))
+}
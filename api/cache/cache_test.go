@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	store := &MemoryStore{values: make(map[string]memoryValue)}
+	rules := []Rule{
+		{Path: regexp.MustCompile(`^/products$`), TTL: time.Minute},
+		{Path: regexp.MustCompile(`^/products/private$`), TTL: 0},
+	}
+
+	calls := 0
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch {
+		case r.Method == http.MethodPost && r.URL.Query().Get("fail") != "":
+			w.WriteHeader(http.StatusBadRequest)
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Query().Get("missing") != "":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("X-Request-ID", "upstream")
+			w.Write([]byte(`[]`))
+		}
+	})
+	handler := Invalidate(store, []string{"products"}, Cache(store, "products", rules, upstream))
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		header    http.Header
+		want      int
+		wantCache string
+		wantCalls int
+	}{
+		{"first read fills the cache", http.MethodGet, "/products", nil, http.StatusOK, "MISS", 1},
+		{"second read is a hit", http.MethodGet, "/products", nil, http.StatusOK, "HIT", 1},
+		{"query is part of the key", http.MethodGet, "/products?page=2", nil, http.StatusOK, "MISS", 2},
+		{"matching ETag is not modified", http.MethodGet, "/products", http.Header{"If-None-Match": {`"v1"`}}, http.StatusNotModified, "HIT", 2},
+		{"no-cache skips the entry", http.MethodGet, "/products", http.Header{"Cache-Control": {"no-cache"}}, http.StatusOK, "MISS", 3},
+		{"errors are not cached", http.MethodGet, "/products?missing=1", nil, http.StatusNotFound, "", 4},
+		{"errors are fetched again", http.MethodGet, "/products?missing=1", nil, http.StatusNotFound, "", 5},
+		{"uncached paths pass through", http.MethodGet, "/products/private", nil, http.StatusOK, "", 6},
+		{"failed write keeps the cache", http.MethodPost, "/products?fail=1", nil, http.StatusBadRequest, "", 7},
+		{"cache kept after a failed write", http.MethodGet, "/products", nil, http.StatusOK, "HIT", 7},
+		{"successful write", http.MethodPost, "/products", nil, http.StatusCreated, "", 8},
+		{"read after a write misses", http.MethodGet, "/products", nil, http.StatusOK, "MISS", 9},
+		{"other keys are dropped too", http.MethodGet, "/products?page=2", nil, http.StatusOK, "MISS", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			for name, values := range tt.header {
+				r.Header[name] = values
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("X-Cache"); got != tt.wantCache {
+				t.Errorf("X-Cache = %q, want %q", got, tt.wantCache)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("upstream called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantCache == "HIT" && w.Header().Get("X-Request-ID") != "" {
+				t.Errorf("hit replays the X-Request-ID of the request that filled the cache")
+			}
+		})
+	}
+}
+
+func TestEntryKey(t *testing.T) {
+	tests := []struct {
+		group      string
+		generation string
+		target     string
+		want       string
+	}{
+		{"products", "0", "/products", "cache:products:0:/products"},
+		{"products", "3", "/products?page=2", "cache:products:3:/products?page=2"},
+		{"orders", "0", "/products", "cache:orders:0:/products"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if got := entryKey(tt.group, tt.generation, r); got != tt.want {
+				t.Errorf("entryKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
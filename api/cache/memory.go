@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxMemoryEntries bounds the memory store; once it is full, new values
+// are dropped until expired ones are swept.
+const maxMemoryEntries = 10000
+
+type memoryValue struct {
+	value   []byte
+	expires time.Time
+}
+
+type MemoryStore struct {
+	mu     sync.Mutex
+	values map[string]memoryValue
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		values: make(map[string]memoryValue),
+	}
+
+	go s.evictExpired(time.Minute)
+
+	return s
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.values[key]
+	if !ok || !v.expires.IsZero() && time.Now().After(v.expires) {
+		return nil, nil
+	}
+
+	return v.value, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.values) >= maxMemoryEntries {
+		return nil
+	}
+
+	s.values[key] = memoryValue{value: value, expires: time.Now().Add(ttl)}
+
+	return nil
+}
+
+// Incr increments the counter at key. Counters never expire.
+func (s *MemoryStore) Incr(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, _ := strconv.ParseInt(string(s.values[key].value), 10, 64)
+	s.values[key] = memoryValue{value: []byte(strconv.FormatInt(n+1, 10))}
+
+	return nil
+}
+
+func (s *MemoryStore) evictExpired(every time.Duration) {
+	for range time.Tick(every) {
+		now := time.Now()
+
+		s.mu.Lock()
+		for key, v := range s.values {
+			if !v.expires.IsZero() && now.After(v.expires) {
+				delete(s.values, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// maxEntryBody is the largest response body that is cached.
+const maxEntryBody = 1 << 20
+
+// cachedHeaders are kept with an entry; the rest, like X-Request-ID,
+// belong to the request that filled the cache.
+var cachedHeaders = []string{"Content-Type", "Content-Language", "Cache-Control", "ETag", "Last-Modified"}
+
+// Cache serves GET requests matching one of the rules from the cache of
+// group, filling it on a miss. Conditional requests are answered from the
+// entry, so a fresh entry never costs an upstream call. Only routes that
+// answer every caller the same may be cached.
+func Cache(store Store, group string, rules []Rule, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ttl, ok := match(rules, r)
+		if !ok || r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+
+		gen, err := generation(ctx, store, group)
+		if err != nil {
+			// a broken cache backend should not take the routes down
+			log.Error("response cache unavailable, bypassing it", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := entryKey(group, gen, r)
+
+		if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+			if entry := load(ctx, store, key); entry != nil {
+				w.Header().Set("X-Cache", "HIT")
+				serve(w, r, entry)
+				return
+			}
+		}
+
+		// fetch the full response, the client's conditions are checked
+		// against it afterwards
+		out := r.Clone(ctx)
+		out.Header.Del("If-None-Match")
+		out.Header.Del("If-Modified-Since")
+
+		buf := &bufferWriter{ResponseWriter: w, header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(buf, out)
+
+		entry := &Entry{Status: buf.status, Header: http.Header{}, Body: buf.body.Bytes(), StoredAt: time.Now()}
+		if buf.status != http.StatusOK || buf.body.Len() > maxEntryBody {
+			entry.Header = buf.header
+			write(w, entry)
+			return
+		}
+
+		for _, name := range cachedHeaders {
+			if value := buf.header.Get(name); value != "" {
+				entry.Header.Set(name, value)
+			}
+		}
+
+		if value, err := json.Marshal(entry); err == nil {
+			if err := store.Set(ctx, key, value, ttl); err != nil {
+				log.Error("failed to store cached response", "error", err)
+			}
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+		serve(w, r, entry)
+	})
+}
+
+// Invalidate drops the cached responses of groups once a write through
+// next succeeds, before its response reaches the client, so the client's
+// next read already misses the cache.
+func Invalidate(store Store, groups []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(&invalidatingWriter{ResponseWriter: w, invalidate: func() {
+			Drop(r.Context(), store, groups)
+		}}, r)
+	})
+}
+
+// Drop moves groups to a new generation, so their cached responses are
+// never served again. Writes that do not go through Invalidate call it
+// once they succeed.
+func Drop(ctx context.Context, store Store, groups []string) {
+	for _, group := range groups {
+		if err := store.Incr(ctx, generationKey(group)); err != nil {
+			logger.FromContext(ctx).Error("failed to invalidate cached responses", "group", group, "error", err)
+		}
+	}
+}
+
+func load(ctx context.Context, store Store, key string) *Entry {
+	value, err := store.Get(ctx, key)
+	if err != nil || value == nil {
+		return nil
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil
+	}
+
+	return &entry
+}
+
+// serve writes the entry, or 304 Not Modified when the client's copy
+// matches it.
+func serve(w http.ResponseWriter, r *http.Request, entry *Entry) {
+	w.Header().Set("Age", age(entry))
+
+	lastModified, _ := http.ParseTime(entry.Header.Get("Last-Modified"))
+	if utils.NotModified(r, entry.Header.Get("ETag"), lastModified) {
+		for _, name := range []string{"Cache-Control", "ETag", "Last-Modified"} {
+			if value := entry.Header.Get(name); value != "" {
+				w.Header().Set(name, value)
+			}
+		}
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	write(w, entry)
+}
+
+func write(w http.ResponseWriter, entry *Entry) {
+	for name, values := range entry.Header {
+		w.Header()[name] = values
+	}
+
+	w.WriteHeader(entry.Status)
+	w.Write(entry.Body)
+}
+
+// bufferWriter holds the whole response back so it can be cached.
+type bufferWriter struct {
+	http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// Flush is a no-op, the response is written once it is complete.
+func (b *bufferWriter) Flush() {}
+
+func (b *bufferWriter) RecordError(err error) {
+	if rec, ok := b.ResponseWriter.(interface{ RecordError(error) }); ok {
+		rec.RecordError(err)
+	}
+}
+
+type invalidatingWriter struct {
+	http.ResponseWriter
+	invalidate  func()
+	wroteHeader bool
+}
+
+func (iw *invalidatingWriter) WriteHeader(status int) {
+	if !iw.wroteHeader {
+		iw.wroteHeader = true
+		if status < http.StatusBadRequest {
+			iw.invalidate()
+		}
+	}
+
+	iw.ResponseWriter.WriteHeader(status)
+}
+
+func (iw *invalidatingWriter) Write(p []byte) (int, error) {
+	if !iw.wroteHeader {
+		iw.WriteHeader(http.StatusOK)
+	}
+
+	return iw.ResponseWriter.Write(p)
+}
+
+func (iw *invalidatingWriter) Flush() {
+	http.NewResponseController(iw.ResponseWriter).Flush()
+}
+
+func (iw *invalidatingWriter) RecordError(err error) {
+	if rec, ok := iw.ResponseWriter.(interface{ RecordError(error) }); ok {
+		rec.RecordError(err)
+	}
+}
+
+func (iw *invalidatingWriter) Unwrap() http.ResponseWriter {
+	return iw.ResponseWriter
+}
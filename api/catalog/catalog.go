@@ -0,0 +1,84 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/4lerman/e_com/common/rpc"
+	productv1 "github.com/4lerman/e_com/proto/product/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ErrNotConnected is returned by Products before Connect succeeded.
+var ErrNotConnected = errors.New("product catalog is not connected")
+
+// marshal renders products like the product service's REST API does: the
+// proto json names are the REST keys, and zero values are kept.
+var marshal = protojson.MarshalOptions{EmitUnpopulated: true}
+
+var (
+	mu      sync.RWMutex
+	conn    *grpc.ClientConn
+	client  productv1.ProductStoreClient
+	timeout time.Duration
+)
+
+// Connect sets up the typed client the gateway reads products with, over
+// the product service's gRPC API at addr. Calls time out after t.
+func Connect(addr string, t time.Duration) error {
+	c, err := rpc.Dial(addr)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+	conn, client, timeout = c, productv1.NewProductStoreClient(c), t
+
+	return nil
+}
+
+// Products fetches the products with the given ids in one call, as JSON
+// documents keyed by id. Products that do not exist are missing from the
+// map.
+func Products(ctx context.Context, ids []int) (map[int]json.RawMessage, error) {
+	mu.RLock()
+	c, t := client, timeout
+	mu.RUnlock()
+
+	if c == nil {
+		return nil, ErrNotConnected
+	}
+
+	req := &productv1.GetProductsByIDsRequest{Ids: make([]int32, len(ids))}
+	for i, id := range ids {
+		req.Ids[i] = int32(id)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t)
+	defer cancel()
+
+	list, err := c.GetProductsByIDs(ctx, req)
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	products := make(map[int]json.RawMessage, len(list.GetProducts()))
+	for _, product := range list.GetProducts() {
+		b, err := marshal.Marshal(product)
+		if err != nil {
+			return nil, err
+		}
+		products[int(product.GetId())] = b
+	}
+
+	return products, nil
+}
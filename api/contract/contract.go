@@ -0,0 +1,80 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+const problemJSON = "application/problem+json"
+
+func init() {
+	openapi3filter.RegisterBodyDecoder(problemJSON, openapi3filter.JSONBodyDecoder)
+	// keep violations on one log line, without the schema and value dumps
+	openapi3.SchemaErrorDetailsDisabled = true
+}
+
+// Spec is the API contract the gateway enforces.
+type Spec struct {
+	router routers.Router
+}
+
+// Load reads the generated Swagger 2.0 document and serves the paths it
+// describes under each of the API version prefixes.
+func Load(doc string, prefixes []string) (*Spec, error) {
+	var v2 openapi2.T
+	if err := json.Unmarshal([]byte(doc), &v2); err != nil {
+		return nil, fmt.Errorf("failed to read the API spec: %w", err)
+	}
+
+	v3, err := openapi2conv.ToV3(&v2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the API spec: %w", err)
+	}
+
+	v3.Servers = openapi3.Servers{}
+	for _, prefix := range prefixes {
+		v3.Servers = append(v3.Servers, &openapi3.Server{URL: prefix})
+	}
+
+	allowProblems(v3)
+
+	if err := v3.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid API spec: %w", err)
+	}
+
+	router, err := gorillamux.NewRouter(v3)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spec{router: router}, nil
+}
+
+// allowProblems lets error responses be problem details: Swagger 2.0 has
+// one content type for all responses of an operation, while errors are
+// answered as application/problem+json.
+func allowProblems(doc *openapi3.T) {
+	for _, path := range doc.Paths.Map() {
+		for _, operation := range path.Operations() {
+			for status, response := range operation.Responses.Map() {
+				code, err := strconv.Atoi(status)
+				if err != nil || code < 400 || response.Value == nil {
+					continue
+				}
+
+				if mediaType := response.Value.Content.Get("application/json"); mediaType != nil {
+					response.Value.Content[problemJSON] = mediaType
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/4lerman/e_com/common/utils"
+)
+
+const testDoc = `{
+	"swagger": "2.0",
+	"info": {"title": "test", "version": "1"},
+	"paths": {
+		"/orders": {
+			"post": {
+				"consumes": ["application/json"],
+				"produces": ["application/json"],
+				"parameters": [{"in": "body", "name": "payload", "required": true, "schema": {"$ref": "#/definitions/CreateOrder"}}],
+				"responses": {"201": {"description": "created", "schema": {"$ref": "#/definitions/Order"}}}
+			}
+		},
+		"/products/{id}": {
+			"get": {
+				"produces": ["application/json"],
+				"parameters": [
+					{"in": "path", "name": "id", "required": true, "type": "integer"},
+					{"in": "query", "name": "currency", "type": "string", "enum": ["USD", "EUR"]}
+				],
+				"responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/Order"}}}
+			}
+		}
+	},
+	"definitions": {
+		"CreateOrder": {
+			"type": "object",
+			"required": ["items"],
+			"properties": {
+				"items": {
+					"type": "array",
+					"minItems": 1,
+					"items": {
+						"type": "object",
+						"required": ["product_id", "quantity"],
+						"properties": {
+							"product_id": {"type": "integer"},
+							"quantity": {"type": "integer", "minimum": 1}
+						}
+					}
+				}
+			}
+		},
+		"Order": {
+			"type": "object",
+			"required": ["id"],
+			"properties": {"id": {"type": "integer"}}
+		}
+	}
+}`
+
+func TestMiddlewareRequests(t *testing.T) {
+	spec, err := Load(testDoc, []string{"/api/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+		fields []string
+	}{
+		{"valid body", http.MethodPost, "/api/v1/orders", `{"items":[{"product_id":1,"quantity":2}]}`, http.StatusCreated, nil},
+		{"missing field", http.MethodPost, "/api/v1/orders", `{}`, http.StatusBadRequest, []string{"items"}},
+		{"empty list", http.MethodPost, "/api/v1/orders", `{"items":[]}`, http.StatusBadRequest, []string{"items"}},
+		{"every bad item field", http.MethodPost, "/api/v1/orders", `{"items":[{"product_id":"one","quantity":0}]}`, http.StatusBadRequest, []string{"items[0].product_id", "items[0].quantity"}},
+		{"invalid JSON", http.MethodPost, "/api/v1/orders", `{"items":`, http.StatusBadRequest, []string{"body"}},
+		{"missing body", http.MethodPost, "/api/v1/orders", ``, http.StatusBadRequest, []string{"body"}},
+		{"valid path and query", http.MethodGet, "/api/v1/products/3?currency=EUR", ``, http.StatusOK, nil},
+		{"bad path parameter", http.MethodGet, "/api/v1/products/three", ``, http.StatusBadRequest, []string{"id"}},
+		{"bad query parameter", http.MethodGet, "/api/v1/products/3?currency=GBP", ``, http.StatusBadRequest, []string{"currency"}},
+		{"undescribed path passes", http.MethodPost, "/api/v1/carts", `{"anything":true}`, http.StatusOK, nil},
+		{"undescribed prefix passes", http.MethodGet, "/api/v3/products/three", ``, http.StatusOK, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Middleware(spec, false, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/orders") {
+					w.WriteHeader(http.StatusCreated)
+				}
+			}))
+
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want != http.StatusBadRequest {
+				return
+			}
+
+			var problem utils.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid problem: %v", err)
+			}
+
+			var fields []string
+			for _, fieldErr := range problem.Errors {
+				fields = append(fields, fieldErr.Field)
+			}
+			sort.Strings(fields)
+
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestMiddlewareResponses(t *testing.T) {
+	spec, err := Load(testDoc, []string{"/api/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		violation bool
+	}{
+		{"matching body", http.StatusOK, `{"id":3}`, false},
+		{"missing field", http.StatusOK, `{"name":"phone"}`, true},
+		{"undocumented status", http.StatusTeapot, `{}`, true},
+		{"answered by the gateway", http.StatusBadGateway, `{}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+
+			handler := Middleware(spec, true, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/products/3", nil))
+
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("response = %d %s, want it passed through as %d %s", w.Code, w.Body, tt.status, tt.body)
+			}
+			if violation := strings.Contains(logs.String(), "does not match the API spec"); violation != tt.violation {
+				t.Errorf("violation = %v, want %v", violation, tt.violation)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package contract
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var violations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "contract_violations_total",
+	Help: "Requests rejected and responses logged for not matching the API spec, by direction (request, response), method and spec path.",
+}, []string{"direction", "method", "path"})
@@ -0,0 +1,203 @@
+package contract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// maxResponseSize is the largest response body that is checked.
+const maxResponseSize = 1 << 20
+
+var options = &openapi3filter.Options{
+	MultiError: true,
+	// callers are authenticated by the gateway, not here
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	// requests are passed on as the client sent them
+	SkipSettingDefaults:   true,
+	IncludeResponseStatus: true,
+}
+
+// Middleware rejects requests that do not match the operation the spec
+// describes for them with 400, listing every offending parameter and body
+// field. Requests for paths or methods the spec does not describe pass
+// through. With checkResponses, responses are checked as well and those
+// that do not match are logged, but still sent as they are.
+func Middleware(spec *Spec, checkResponses bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, params, err := spec.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// the services read bodies as JSON whatever their content type
+		if r.Header.Get("Content-Type") == "" && r.ContentLength != 0 {
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: params,
+			Route:      route,
+			Options:    options,
+		}
+
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			violations.WithLabelValues("request", r.Method, route.Path).Inc()
+			utils.WriteFieldErrors(w, r, err, fieldErrors(err, ""))
+			return
+		}
+
+		if !checkResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if err := checkResponse(input, rec); err != nil {
+			violations.WithLabelValues("response", r.Method, route.Path).Inc()
+			logger.FromContext(r.Context()).Warn("response does not match the API spec",
+				"operation", r.Method+" "+route.Path, "status", rec.status, "error", err)
+		}
+	})
+}
+
+func checkResponse(input *openapi3filter.RequestValidationInput, rec *recorder) error {
+	switch rec.status {
+	// answered by the gateway or its cache, not by the handler
+	case http.StatusNotModified, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return nil
+	}
+
+	opts := *options
+	if rec.truncated || rec.Header().Get("Content-Encoding") != "" {
+		opts.ExcludeResponseBody = true
+	}
+
+	return openapi3filter.ValidateResponse(input.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.status,
+		Header:                 rec.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+		Options:                &opts,
+	})
+}
+
+// fieldErrors lists what the validator reported, one entry per offending
+// parameter or body field. field is the parameter the error is about.
+func fieldErrors(err error, field string) []utils.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var errs []utils.FieldError
+		for _, inner := range e {
+			errs = append(errs, fieldErrors(inner, field)...)
+		}
+		return errs
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			field = e.Parameter.Name
+		}
+
+		switch e.Err.(type) {
+		case openapi3.MultiError, *openapi3.SchemaError, *openapi3filter.ParseError:
+			return fieldErrors(e.Err, field)
+		}
+
+		code := "invalid"
+		if errors.Is(e.Err, openapi3filter.ErrInvalidRequired) {
+			code = "required"
+		}
+		return []utils.FieldError{{Field: orBody(field), Code: code, Message: e.Error()}}
+	case *openapi3.SchemaError:
+		return []utils.FieldError{{Field: orBody(joinPath(field, e.JSONPointer())), Code: e.SchemaField, Message: e.Reason}}
+	case *openapi3filter.ParseError:
+		var pointer []string
+		for _, part := range e.Path() {
+			pointer = append(pointer, fmt.Sprint(part))
+		}
+		return []utils.FieldError{{Field: orBody(joinPath(field, pointer)), Code: "type", Message: e.Error()}}
+	}
+
+	return []utils.FieldError{{Field: orBody(field), Code: "invalid", Message: err.Error()}}
+}
+
+// joinPath renders a JSON pointer below field like the validation errors of
+// the services do: items[0].quantity.
+func joinPath(field string, pointer []string) string {
+	path := field
+	for _, part := range pointer {
+		if _, err := strconv.Atoi(part); err == nil {
+			path += "[" + part + "]"
+			continue
+		}
+
+		if path != "" {
+			path += "."
+		}
+		path += part
+	}
+
+	return path
+}
+
+func orBody(field string) string {
+	if field == "" {
+		return "body"
+	}
+
+	return field
+}
+
+// recorder passes the response through and keeps a copy of its beginning
+// to check.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	truncated   bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	if rec.body.Len()+len(b) > maxResponseSize {
+		rec.truncated = true
+	} else {
+		rec.body.Write(b)
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// RecordError passes handler errors on to the access log.
+func (rec *recorder) RecordError(err error) {
+	if r, ok := rec.ResponseWriter.(interface{ RecordError(error) }); ok {
+		r.RecordError(err)
+	}
+}
+
+func (rec *recorder) Flush() {
+	http.NewResponseController(rec.ResponseWriter).Flush()
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,199 @@
+package details
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/4lerman/e_com/api/catalog"
+	"github.com/4lerman/e_com/api/upstream"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// OrderDetails is an order together with everything needed to show it.
+// The documents are passed on as the services return them. Sections that
+// could not be loaded are left empty and listed in Errors.
+type OrderDetails struct {
+	Order    json.RawMessage   `json:"order" swaggertype:"object"`
+	Items    []Item            `json:"items"`
+	Customer json.RawMessage   `json:"customer" swaggertype:"object"`
+	Payments []json.RawMessage `json:"payments" swaggertype:"array,object"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// Item is an order line with a snapshot of its product.
+type Item struct {
+	Item    json.RawMessage `json:"item" swaggertype:"object"`
+	Product json.RawMessage `json:"product" swaggertype:"object"`
+}
+
+// The fields the gateway needs to follow references between documents.
+type order struct {
+	UserID int `json:"user_id"`
+}
+
+type orderItem struct {
+	ProductID int `json:"productID"`
+}
+
+// StatusError is a non-200 response of an upstream service.
+type StatusError struct {
+	Service string
+	Status  int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Service, e.Message)
+}
+
+// Load fetches the order, then its items, products, customer and payments
+// concurrently, on behalf of the caller. Only the order itself is
+// essential: if it fails Load returns the error, other failures are
+// reported per section.
+func Load(ctx context.Context, caller identity.Identity, orderId int) (*OrderDetails, error) {
+	d := &OrderDetails{
+		Items:    []Item{},
+		Payments: []json.RawMessage{},
+	}
+
+	if err := Get(ctx, caller, "orders", "/orders/"+strconv.Itoa(orderId), &d.Order); err != nil {
+		return nil, err
+	}
+
+	var o order
+	if err := json.Unmarshal(d.Order, &o); err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	fail := func(section string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if d.Errors == nil {
+			d.Errors = map[string]string{}
+		}
+		d.Errors[section] = err.Error()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+
+		var items []json.RawMessage
+		if err := Get(ctx, caller, "orders", "/orders/"+strconv.Itoa(orderId)+"/items", &items); err != nil {
+			fail("items", err)
+			return
+		}
+
+		productIds := make([]int, len(items))
+		for i, item := range items {
+			var ref orderItem
+			if err := json.Unmarshal(item, &ref); err != nil {
+				fail("items", err)
+				return
+			}
+			productIds[i] = ref.ProductID
+		}
+
+		products, err := loadProducts(ctx, productIds)
+		if err != nil {
+			fail("products", err)
+		}
+
+		for i, item := range items {
+			d.Items = append(d.Items, Item{Item: item, Product: products[productIds[i]]})
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		if err := Get(ctx, caller, "users", "/users/"+strconv.Itoa(o.UserID), &d.Customer); err != nil {
+			fail("customer", err)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		var payments []json.RawMessage
+		if err := Get(ctx, caller, "payments", "/payments/search?order="+strconv.Itoa(orderId), &payments); err != nil {
+			fail("payments", err)
+			return
+		}
+		d.Payments = payments
+	}()
+
+	wg.Wait()
+
+	return d, nil
+}
+
+// loadProducts fetches every distinct product in one call to the product
+// catalog. Products that do not exist are missing from the map, and the
+// first of them is returned as the error.
+func loadProducts(ctx context.Context, productIds []int) (map[int]json.RawMessage, error) {
+	seen := map[int]bool{}
+	ids := []int{}
+	for _, productId := range productIds {
+		if !seen[productId] {
+			seen[productId] = true
+			ids = append(ids, productId)
+		}
+	}
+
+	products, err := catalog.Products(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, productId := range ids {
+		if _, ok := products[productId]; !ok {
+			return products, fmt.Errorf("product %d: product not found", productId)
+		}
+	}
+
+	return products, nil
+}
+
+// Get fetches path from the named upstream into v, passing the caller's
+// identity on so the services apply their ownership rules.
+func Get(ctx context.Context, caller identity.Identity, service, path string, v any) error {
+	client := upstream.For(service)
+	req, err := client.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	identity.Set(req, caller)
+
+	resp, err := client.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{Service: service, Status: resp.StatusCode, Message: ErrorMessage(resp)}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// ErrorMessage reads the detail of the problem a service answered with.
+func ErrorMessage(resp *http.Response) string {
+	var problem utils.Problem
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&problem); err != nil || problem.Detail == "" {
+		return resp.Status
+	}
+
+	return problem.Detail
+}
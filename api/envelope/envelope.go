@@ -0,0 +1,138 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// Envelope is the shape of every /api/v2 response body: data on success,
+// error on failure and meta in both cases.
+type Envelope struct {
+	Data  json.RawMessage `json:"data" swaggertype:"object"`
+	Error *Error          `json:"error"`
+	Meta  Meta            `json:"meta"`
+}
+
+// Error carries the problem a service answered with; Errors lists the
+// invalid fields of a validation problem.
+type Error struct {
+	Status  int                `json:"status"`
+	Message string             `json:"message"`
+	Errors  []utils.FieldError `json:"errors,omitempty"`
+}
+
+type Meta struct {
+	RequestID string `json:"requestId,omitempty"`
+	// Count is the number of items when data is a list.
+	Count *int `json:"count,omitempty"`
+	// Message replaces the bare {"msg": ...} bodies of v1.
+	Message string `json:"message,omitempty"`
+}
+
+// Middleware wraps the JSON responses of next in an Envelope. It asks the
+// services for the created resource instead of a status message, so v2
+// creates answer 201 with the entity. Bodiless responses such as 204 and
+// 304, and bodies that are not JSON, pass through unchanged.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("Prefer", "return=representation")
+
+		buf := &bufferWriter{ResponseWriter: w, header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+
+		for name, values := range buf.header {
+			w.Header()[name] = values
+		}
+
+		// the envelope is not byte-identical to what the upstream tagged,
+		// 304s carry the same weak tag so revalidation keeps working
+		if etag := w.Header().Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			w.Header().Set("ETag", "W/"+etag)
+		}
+
+		body := bytes.TrimSpace(buf.body.Bytes())
+		if len(body) == 0 || !json.Valid(body) {
+			w.WriteHeader(buf.status)
+			w.Write(buf.body.Bytes())
+			return
+		}
+
+		env := wrap(buf.status, body)
+		env.Meta.RequestID = logger.RequestID(r.Context())
+
+		out, err := json.Marshal(env)
+		if err != nil {
+			w.WriteHeader(buf.status)
+			w.Write(buf.body.Bytes())
+			return
+		}
+
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(buf.status)
+		w.Write(out)
+	})
+}
+
+func wrap(status int, body []byte) Envelope {
+	if status >= http.StatusBadRequest {
+		var problem utils.Problem
+		if err := json.Unmarshal(body, &problem); err != nil || problem.Detail == "" {
+			problem.Detail = http.StatusText(status)
+		}
+
+		return Envelope{Error: &Error{Status: status, Message: problem.Detail, Errors: problem.Errors}}
+	}
+
+	var message map[string]json.RawMessage
+	if json.Unmarshal(body, &message) == nil && len(message) == 1 {
+		var msg string
+		if json.Unmarshal(message["msg"], &msg) == nil && msg != "" {
+			return Envelope{Meta: Meta{Message: msg}}
+		}
+	}
+
+	env := Envelope{Data: body}
+
+	var list []json.RawMessage
+	if body[0] == '[' && json.Unmarshal(body, &list) == nil {
+		count := len(list)
+		env.Meta.Count = &count
+	}
+
+	return env
+}
+
+// bufferWriter holds the whole response back so it can be wrapped.
+type bufferWriter struct {
+	http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// Flush is a no-op, the response is written once it is complete.
+func (b *bufferWriter) Flush() {}
+
+func (b *bufferWriter) RecordError(err error) {
+	if rec, ok := b.ResponseWriter.(interface{ RecordError(error) }); ok {
+		rec.RecordError(err)
+	}
+}
@@ -0,0 +1,124 @@
+package envelope
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		etag     string
+		want     string
+		wantETag string
+	}{
+		{
+			name:   "object",
+			status: http.StatusOK,
+			body:   `{"id":1,"name":"phone"}`,
+			want:   `{"data":{"id":1,"name":"phone"},"error":null,"meta":{}}`,
+		},
+		{
+			name:   "list is counted",
+			status: http.StatusOK,
+			body:   `[{"id":1},{"id":2}]`,
+			want:   `{"data":[{"id":1},{"id":2}],"error":null,"meta":{"count":2}}`,
+		},
+		{
+			name:   "empty list",
+			status: http.StatusOK,
+			body:   "[]\n",
+			want:   `{"data":[],"error":null,"meta":{"count":0}}`,
+		},
+		{
+			name:   "status message moves to meta",
+			status: http.StatusOK,
+			body:   `{"msg":"user deleted"}`,
+			want:   `{"data":null,"error":null,"meta":{"message":"user deleted"}}`,
+		},
+		{
+			name:   "created entity",
+			status: http.StatusCreated,
+			body:   `{"id":7}`,
+			want:   `{"data":{"id":7},"error":null,"meta":{}}`,
+		},
+		{
+			name:   "problem",
+			status: http.StatusNotFound,
+			body:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"product not found"}`,
+			want:   `{"data":null,"error":{"status":404,"message":"product not found"},"meta":{}}`,
+		},
+		{
+			name:   "validation problem keeps the fields",
+			status: http.StatusBadRequest,
+			body:   `{"status":400,"detail":"invalid payload","errors":[{"field":"email","code":"email","message":"email must be a valid email"}]}`,
+			want:   `{"data":null,"error":{"status":400,"message":"invalid payload","errors":[{"field":"email","code":"email","message":"email must be a valid email"}]},"meta":{}}`,
+		},
+		{
+			name:   "error without detail",
+			status: http.StatusBadGateway,
+			body:   `{}`,
+			want:   `{"data":null,"error":{"status":502,"message":"Bad Gateway"},"meta":{}}`,
+		},
+		{
+			name:   "non-JSON body passes through",
+			status: http.StatusOK,
+			body:   "ok",
+			want:   "ok",
+		},
+		{
+			name:   "empty body passes through",
+			status: http.StatusNoContent,
+			want:   "",
+		},
+		{
+			name:     "strong ETag is weakened",
+			status:   http.StatusOK,
+			body:     `{"id":1}`,
+			etag:     `"abc"`,
+			want:     `{"data":{"id":1},"error":null,"meta":{}}`,
+			wantETag: `W/"abc"`,
+		},
+		{
+			name:     "weak ETag is kept",
+			status:   http.StatusNotModified,
+			etag:     `W/"abc"`,
+			want:     "",
+			wantETag: `W/"abc"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prefer string
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				prefer = r.Header.Get("Prefer")
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/products", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if prefer != "return=representation" {
+				t.Errorf("Prefer = %q, want return=representation", prefer)
+			}
+		})
+	}
+}
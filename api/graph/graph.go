@@ -0,0 +1,262 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/4lerman/e_com/api/catalog"
+	"github.com/4lerman/e_com/api/details"
+	"github.com/4lerman/e_com/api/upstream"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Authorizer decides whether the caller may make an upstream call, so
+// resolvers follow the same route policies as the REST API, and returns the
+// identity to make it with.
+type Authorizer func(caller identity.Identity, service, method, path string) (identity.Identity, bool)
+
+// Invalidator drops the gateway's cached responses that a successful write
+// to service made stale, as writes through the REST routes do.
+type Invalidator func(ctx context.Context, service string)
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// Schema executes GraphQL requests against the domain services' REST APIs.
+type Schema struct {
+	schema     graphql.Schema
+	authorize  Authorizer
+	invalidate Invalidator
+}
+
+func New(authorize Authorizer, invalidate Invalidator) (*Schema, error) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid graphql schema: %w", err)
+	}
+
+	return &Schema{schema: schema, authorize: authorize, invalidate: invalidate}, nil
+}
+
+// Execute runs the request on behalf of the caller, with loaders scoped to
+// this request. Queries over the depth or field limits are refused before
+// they cost any upstream call.
+func (s *Schema) Execute(ctx context.Context, caller identity.Identity, req Request) *graphql.Result {
+	if err := checkLimits(req.Query); err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}}
+	}
+
+	state := &state{caller: caller, authorize: s.authorize, invalidate: s.invalidate}
+	state.products = NewLoader(state.fetchProducts)
+	state.users = NewLoader(each(state.fetchUser))
+	state.orders = NewLoader(each(state.fetchOrder))
+	state.items = NewLoader(each(state.fetchItems))
+	state.orderPayments = NewLoader(each(state.fetchOrderPayments))
+	state.userOrders = NewLoader(each(state.fetchUserOrders))
+	state.userPayments = NewLoader(each(state.fetchUserPayments))
+
+	return graphql.Do(graphql.Params{
+		Schema:         s.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        context.WithValue(ctx, contextKey{}, state),
+	})
+}
+
+// HasMutation reports whether the query contains a mutation, which must
+// not be sent with a safe method.
+func HasMutation(query string) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return false
+	}
+
+	for _, definition := range doc.Definitions {
+		if op, ok := definition.(*ast.OperationDefinition); ok && op.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+
+	return false
+}
+
+type contextKey struct{}
+
+// state is what resolvers of one request share.
+type state struct {
+	caller     identity.Identity
+	authorize  Authorizer
+	invalidate Invalidator
+
+	products      *Loader
+	users         *Loader
+	orders        *Loader
+	items         *Loader
+	orderPayments *Loader
+	userOrders    *Loader
+	userPayments  *Loader
+}
+
+func stateFrom(ctx context.Context) *state {
+	return ctx.Value(contextKey{}).(*state)
+}
+
+// document is a JSON object as a service returned it.
+type document = map[string]any
+
+// call sends a request to the named upstream on behalf of the caller and
+// decodes the response into v. Creates ask for the created resource back.
+func (s *state) call(ctx context.Context, service, method, path string, body, v any) error {
+	caller, err := s.allowed(service, method, path)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	client := upstream.For(service)
+	req, err := client.NewRequest(ctx, method, path, reader)
+	if err != nil {
+		return err
+	}
+	identity.Set(req, caller)
+	if method == http.MethodPost {
+		req.Header.Set("Prefer", "return=representation")
+	}
+
+	resp, err := client.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return &details.StatusError{Service: service, Status: resp.StatusCode, Message: details.ErrorMessage(resp)}
+	}
+
+	if method != http.MethodGet && s.invalidate != nil {
+		s.invalidate(ctx, service)
+	}
+
+	if v == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (s *state) get(ctx context.Context, service, path string, v any) error {
+	return s.call(ctx, service, http.MethodGet, path, nil, v)
+}
+
+// allowed checks the caller may make the request through the gateway, and
+// returns the identity to make it with.
+func (s *state) allowed(service, method, path string) (identity.Identity, error) {
+	route, _, _ := strings.Cut(path, "?")
+	caller, ok := s.authorize(s.caller, service, method, route)
+	if !ok {
+		return caller, &details.StatusError{Service: service, Status: http.StatusForbidden, Message: fmt.Sprintf("%s role is not allowed to %s %s", s.caller.Role, method, route)}
+	}
+
+	return caller, nil
+}
+
+// fetchProducts loads a batch of products in one call to the product
+// catalog.
+func (s *state) fetchProducts(ctx context.Context, ids []int) map[int]result {
+	results := make(map[int]result, len(ids))
+
+	fail := func(err error) map[int]result {
+		for _, id := range ids {
+			results[id] = result{err: err}
+		}
+		return results
+	}
+
+	if _, err := s.allowed("products", http.MethodGet, "/products"); err != nil {
+		return fail(err)
+	}
+
+	products, err := catalog.Products(ctx, ids)
+	if err != nil {
+		return fail(err)
+	}
+
+	for id, raw := range products {
+		var product document
+		if err := json.Unmarshal(raw, &product); err != nil {
+			return fail(err)
+		}
+		results[id] = result{value: product}
+	}
+
+	return results
+}
+
+func (s *state) fetchUser(ctx context.Context, id int) (any, error) {
+	var user document
+	err := s.get(ctx, "users", "/users/"+strconv.Itoa(id), &user)
+	return user, err
+}
+
+func (s *state) fetchOrder(ctx context.Context, id int) (any, error) {
+	var order document
+	err := s.get(ctx, "orders", "/orders/"+strconv.Itoa(id), &order)
+	return order, err
+}
+
+func (s *state) fetchItems(ctx context.Context, orderId int) (any, error) {
+	var items []document
+	err := s.get(ctx, "orders", "/orders/"+strconv.Itoa(orderId)+"/items", &items)
+	return items, err
+}
+
+func (s *state) fetchOrderPayments(ctx context.Context, orderId int) (any, error) {
+	var payments []document
+	err := s.get(ctx, "payments", "/payments/search?order="+strconv.Itoa(orderId), &payments)
+	return payments, err
+}
+
+func (s *state) fetchUserOrders(ctx context.Context, userId int) (any, error) {
+	var orders []document
+	err := s.get(ctx, "orders", "/orders/search?user="+strconv.Itoa(userId), &orders)
+	return orders, err
+}
+
+func (s *state) fetchUserPayments(ctx context.Context, userId int) (any, error) {
+	var payments []document
+	err := s.get(ctx, "payments", "/payments/search?user="+strconv.Itoa(userId), &payments)
+	return payments, err
+}
+
+// intOf reads a numeric field of a document.
+func intOf(doc document, key string) int {
+	n, _ := doc[key].(float64)
+	return int(n)
+}
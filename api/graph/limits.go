@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	// maxDepth is how deeply fields may nest. Following relations back and
+	// forth, user → orders → user → orders…, fans out into an upstream
+	// call per record at every level.
+	maxDepth = 8
+	// maxFields is how many fields one query may select in all, counting
+	// a fragment's fields at every place it is spread, so aliases cannot
+	// repeat a lookup without bound.
+	maxFields = 200
+)
+
+// checkLimits refuses queries nesting deeper than maxDepth or selecting
+// more than maxFields fields. Queries that do not parse are left to the
+// executor to report.
+func checkLimits(query string) error {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range doc.Definitions {
+		op, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		w := &limitWalker{fragments: fragments, spreading: map[string]bool{}}
+		if err := w.walk(op.SelectionSet, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type limitWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	// spreading holds the fragments being expanded, so a cycle, which
+	// validation rejects anyway, cannot recurse forever
+	spreading map[string]bool
+	fields    int
+}
+
+func (w *limitWalker) walk(set *ast.SelectionSet, depth int) error {
+	if set == nil {
+		return nil
+	}
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if depth > maxDepth {
+				return fmt.Errorf("query is nested deeper than %d levels", maxDepth)
+			}
+			if w.fields++; w.fields > maxFields {
+				return fmt.Errorf("query selects more than %d fields", maxFields)
+			}
+			if err := w.walk(selection.SelectionSet, depth+1); err != nil {
+				return err
+			}
+		case *ast.InlineFragment:
+			if err := w.walk(selection.SelectionSet, depth); err != nil {
+				return err
+			}
+		case *ast.FragmentSpread:
+			if selection.Name == nil {
+				continue
+			}
+			name := selection.Name.Value
+			fragment, ok := w.fragments[name]
+			if !ok || w.spreading[name] {
+				continue
+			}
+			w.spreading[name] = true
+			err := w.walk(fragment.SelectionSet, depth)
+			delete(w.spreading, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
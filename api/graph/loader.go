@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"context"
+	"sync"
+)
+
+type result struct {
+	value any
+	err   error
+}
+
+// batchFunc fetches the documents of keys, reporting each key's result.
+// Keys missing from the map resolve to null.
+type batchFunc func(ctx context.Context, keys []int) map[int]result
+
+// Loader batches and deduplicates lookups within one GraphQL request.
+// Resolvers queue keys with Load and get a thunk back; the executor runs
+// the thunks after resolving a whole level of the query, so the first
+// thunk fetches every key queued by then in a single batch.
+type Loader struct {
+	mu      sync.Mutex
+	fetch   batchFunc
+	pending []int
+	results map[int]result
+}
+
+func NewLoader(fetch batchFunc) *Loader {
+	return &Loader{
+		fetch:   fetch,
+		results: map[int]result{},
+	}
+}
+
+func (l *Loader) Load(ctx context.Context, key int) func() (any, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.results[key] = result{}
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (any, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+
+			fetched := l.fetch(ctx, keys)
+			for _, k := range keys {
+				l.results[k] = fetched[k]
+			}
+		}
+
+		r := l.results[key]
+		return r.value, r.err
+	}
+}
+
+// each fetches the keys one by one, all at the same time, for services
+// without a batch endpoint; the Loader still deduplicates them.
+func each(fetch func(ctx context.Context, key int) (any, error)) batchFunc {
+	return func(ctx context.Context, keys []int) map[int]result {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+
+		results := make(map[int]result, len(keys))
+		for _, key := range keys {
+			wg.Add(1)
+			go func(key int) {
+				defer wg.Done()
+
+				value, err := fetch(ctx, key)
+
+				mu.Lock()
+				defer mu.Unlock()
+				results[key] = result{value: value, err: err}
+			}(key)
+		}
+
+		wg.Wait()
+
+		return results
+	}
+}
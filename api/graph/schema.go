@@ -0,0 +1,407 @@
+package graph
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// The object types mirror user/types, product/types, order/types and
+// payment/types, with camelCase field names; field resolves the JSON key
+// each service uses.
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id":            field("id", graphql.NewNonNull(graphql.Int)),
+		"fullName":      field("full_name", graphql.String),
+		"address":       field("address", graphql.String),
+		"email":         field("email", graphql.String),
+		"registerDate":  field("register_date", graphql.String),
+		"userRole":      field("user_role", graphql.String),
+		"emailVerified": field("email_verified", graphql.Boolean),
+	},
+})
+
+var productType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Product",
+	Fields: graphql.Fields{
+		"id":          field("id", graphql.NewNonNull(graphql.Int)),
+		"name":        field("name", graphql.String),
+		"description": field("description", graphql.String),
+		"price":       field("price", graphql.Float),
+		"quantity":    field("quantity", graphql.Int),
+		"category":    field("category", graphql.String),
+		"createdAt":   field("createdAt", graphql.String),
+		"updatedAt":   field("updatedAt", graphql.String),
+	},
+})
+
+var orderType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Order",
+	Fields: graphql.Fields{
+		"id":        field("id", graphql.NewNonNull(graphql.Int)),
+		"userId":    field("user_id", graphql.Int),
+		"total":     field("total", graphql.Float),
+		"status":    field("status", graphql.String),
+		"createdAt": field("createdAt", graphql.String),
+		// copies of the addresses the order was placed with
+		"shippingAddress": field("shipping_address", addressSnapshotType),
+		"billingAddress":  field("billing_address", addressSnapshotType),
+	},
+})
+
+var addressSnapshotType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AddressSnapshot",
+	Fields: graphql.Fields{
+		"id":         field("id", graphql.Int),
+		"label":      field("label", graphql.String),
+		"country":    field("country", graphql.String),
+		"city":       field("city", graphql.String),
+		"street":     field("street", graphql.String),
+		"postalCode": field("postal_code", graphql.String),
+		"phone":      field("phone", graphql.String),
+	},
+})
+
+var orderItemType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "OrderItem",
+	Fields: graphql.Fields{
+		"id":        field("id", graphql.NewNonNull(graphql.Int)),
+		"orderId":   field("orderI_D", graphql.Int),
+		"productId": field("productID", graphql.Int),
+		"quantity":  field("quantity", graphql.Int),
+		"price":     field("price", graphql.Float),
+		"createdAt": field("createdAt", graphql.String),
+		"product":   reference(productType, "productID", func(s *state) *Loader { return s.products }),
+	},
+})
+
+var paymentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Payment",
+	Fields: graphql.Fields{
+		"id":          field("id", graphql.NewNonNull(graphql.Int)),
+		"userId":      field("user_id", graphql.Int),
+		"orderId":     field("order_id", graphql.Int),
+		"amount":      field("amount", graphql.Float),
+		"paymentDate": field("payment_date", graphql.String),
+		"status":      field("status", graphql.String),
+	},
+})
+
+// The references between users, orders and payments go both ways, so they
+// are added once all the types exist.
+func init() {
+	userType.AddFieldConfig("orders", reference(graphql.NewList(orderType), "id", func(s *state) *Loader { return s.userOrders }))
+	userType.AddFieldConfig("payments", reference(graphql.NewList(paymentType), "id", func(s *state) *Loader { return s.userPayments }))
+
+	orderType.AddFieldConfig("user", reference(userType, "user_id", func(s *state) *Loader { return s.users }))
+	orderType.AddFieldConfig("items", reference(graphql.NewList(orderItemType), "id", func(s *state) *Loader { return s.items }))
+	orderType.AddFieldConfig("payments", reference(graphql.NewList(paymentType), "id", func(s *state) *Loader { return s.orderPayments }))
+
+	paymentType.AddFieldConfig("order", reference(orderType, "order_id", func(s *state) *Loader { return s.orders }))
+	paymentType.AddFieldConfig("user", reference(userType, "user_id", func(s *state) *Loader { return s.users }))
+}
+
+var idArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+}
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"me": &graphql.Field{
+			Type: userType,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				s := stateFrom(p.Context)
+				return s.users.Load(p.Context, s.caller.UserID), nil
+			},
+		},
+		"user":    byId(userType, func(s *state) *Loader { return s.users }),
+		"product": byId(productType, func(s *state) *Loader { return s.products }),
+		"order":   byId(orderType, func(s *state) *Loader { return s.orders }),
+		"payment": &graphql.Field{
+			Type: paymentType,
+			Args: idArgs,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				var payment document
+				err := stateFrom(p.Context).get(p.Context, "payments", "/payments/"+strconv.Itoa(p.Args["id"].(int)), &payment)
+				return payment, err
+			},
+		},
+		"users": search(userType, "users", "/users", map[string]graphql.Input{
+			"name":  graphql.String,
+			"email": graphql.String,
+		}, nil),
+		"products": &graphql.Field{
+			Type: graphql.NewList(productType),
+			Args: graphql.FieldConfigArgument{
+				"ids":      &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+				"name":     &graphql.ArgumentConfig{Type: graphql.String},
+				"category": &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				path := "/products"
+				if ids, ok := p.Args["ids"].([]any); ok {
+					parts := make([]string, len(ids))
+					for i, id := range ids {
+						parts[i] = strconv.Itoa(id.(int))
+					}
+					path += "?ids=" + strings.Join(parts, ",")
+				} else if query := searchQuery(p.Args, nil); query != "" {
+					path += "/search?" + query
+				}
+
+				var products []document
+				err := stateFrom(p.Context).get(p.Context, "products", path, &products)
+				return products, err
+			},
+		},
+		"orders": search(orderType, "orders", "/orders", map[string]graphql.Input{
+			"status": graphql.String,
+			"userId": graphql.Int,
+		}, map[string]string{"userId": "user"}),
+		"payments": search(paymentType, "payments", "/payments", map[string]graphql.Input{
+			"status":  graphql.String,
+			"userId":  graphql.Int,
+			"orderId": graphql.Int,
+		}, map[string]string{"userId": "user", "orderId": "order"}),
+	},
+})
+
+var (
+	createUserInput = inputObject("CreateUserInput", map[string]graphql.Input{
+		"fullName": graphql.NewNonNull(graphql.String),
+		"address":  graphql.NewNonNull(graphql.String),
+		"email":    graphql.NewNonNull(graphql.String),
+		"userRole": graphql.NewNonNull(graphql.String),
+		"password": graphql.String,
+	})
+
+	updateUserInput = inputObject("UpdateUserInput", map[string]graphql.Input{
+		"fullName": graphql.String,
+		"address":  graphql.String,
+		"userRole": graphql.String,
+	})
+
+	createProductInput = inputObject("CreateProductInput", map[string]graphql.Input{
+		"name":        graphql.NewNonNull(graphql.String),
+		"description": graphql.String,
+		"price":       graphql.NewNonNull(graphql.Float),
+		"quantity":    graphql.NewNonNull(graphql.Int),
+		"category":    graphql.NewNonNull(graphql.String),
+	})
+
+	updateProductInput = inputObject("UpdateProductInput", map[string]graphql.Input{
+		"name":        graphql.String,
+		"description": graphql.String,
+		"price":       graphql.Float,
+		"quantity":    graphql.Int,
+		"category":    graphql.String,
+	})
+
+	createOrderInput = inputObject("CreateOrderInput", map[string]graphql.Input{
+		"userId":            graphql.NewNonNull(graphql.Int),
+		"shippingAddressId": graphql.Int,
+		"billingAddressId":  graphql.Int,
+	})
+
+	updateOrderInput = inputObject("UpdateOrderInput", map[string]graphql.Input{
+		"userId": graphql.Int,
+		"total":  graphql.Float,
+		"status": graphql.String,
+	})
+
+	orderItemInput = inputObject("OrderItemInput", map[string]graphql.Input{
+		"productId": graphql.NewNonNull(graphql.Int),
+		"quantity":  graphql.NewNonNull(graphql.Int),
+	})
+
+	paymentInput = inputObject("PaymentInput", map[string]graphql.Input{
+		"userId":  graphql.NewNonNull(graphql.Int),
+		"orderId": graphql.NewNonNull(graphql.Int),
+		"amount":  graphql.NewNonNull(graphql.Float),
+	})
+)
+
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"createUser":    create(userType, "users", createUserInput, func(graphql.ResolveParams) string { return "/users" }),
+		"updateUser":    update(userType, "users", "/users", updateUserInput),
+		"createProduct": create(productType, "products", createProductInput, func(graphql.ResolveParams) string { return "/products" }),
+		"updateProduct": update(productType, "products", "/products", updateProductInput),
+		"createOrder":   create(orderType, "orders", createOrderInput, func(graphql.ResolveParams) string { return "/orders" }),
+		"updateOrder":   update(orderType, "orders", "/orders", updateOrderInput),
+		"addOrderItem": create(orderItemType, "orders", orderItemInput, func(p graphql.ResolveParams) string {
+			return "/orders/" + strconv.Itoa(p.Args["orderId"].(int)) + "/order"
+		}, "orderId"),
+		"createPayment": create(paymentType, "payments", paymentInput, func(graphql.ResolveParams) string { return "/payments" }),
+		"updatePayment": update(paymentType, "payments", "/payments", paymentInput),
+	},
+})
+
+// field resolves to the value the service returns under key.
+func field(key string, typ graphql.Output) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			doc, _ := p.Source.(document)
+			return doc[key], nil
+		},
+	}
+}
+
+// reference resolves a related document through a loader, keyed by the
+// source's key field.
+func reference(typ graphql.Output, key string, loader func(*state) *Loader) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			doc, _ := p.Source.(document)
+			id := intOf(doc, key)
+			if id == 0 {
+				return nil, nil
+			}
+
+			return loader(stateFrom(p.Context)).Load(p.Context, id), nil
+		},
+	}
+}
+
+func byId(typ graphql.Output, loader func(*state) *Loader) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Args: idArgs,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return loader(stateFrom(p.Context)).Load(p.Context, p.Args["id"].(int)), nil
+		},
+	}
+}
+
+// search lists the service's documents, or searches them when any of the
+// filters is given; params renames filters to the service's query params.
+func search(typ graphql.Output, service, path string, filters map[string]graphql.Input, params map[string]string) *graphql.Field {
+	args := graphql.FieldConfigArgument{}
+	for name, input := range filters {
+		args[name] = &graphql.ArgumentConfig{Type: input}
+	}
+
+	return &graphql.Field{
+		Type: graphql.NewList(typ),
+		Args: args,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			path := path
+			if query := searchQuery(p.Args, params); query != "" {
+				path += "/search?" + query
+			}
+
+			var docs []document
+			err := stateFrom(p.Context).get(p.Context, service, path, &docs)
+			return docs, err
+		},
+	}
+}
+
+func searchQuery(args map[string]any, params map[string]string) string {
+	query := url.Values{}
+	for name, value := range args {
+		if param, ok := params[name]; ok {
+			name = param
+		}
+
+		switch v := value.(type) {
+		case string:
+			query.Set(name, v)
+		case int:
+			query.Set(name, strconv.Itoa(v))
+		}
+	}
+
+	return query.Encode()
+}
+
+// jsonKeys are the payload keys of input fields that differ from the
+// field name.
+var jsonKeys = map[string]string{
+	"fullName":  "full_name",
+	"userRole":  "user_role",
+	"userId":    "user_id",
+	"orderId":   "order_id",
+	"productId": "product_id",
+
+	"shippingAddressId": "shipping_address_id",
+	"billingAddressId":  "billing_address_id",
+}
+
+func inputObject(name string, fields map[string]graphql.Input) *graphql.InputObject {
+	config := graphql.InputObjectConfigFieldMap{}
+	for field, typ := range fields {
+		config[field] = &graphql.InputObjectFieldConfig{Type: typ}
+	}
+
+	return graphql.NewInputObject(graphql.InputObjectConfig{Name: name, Fields: config})
+}
+
+// payload turns an input object into the JSON payload the service takes.
+func payload(input any) map[string]any {
+	fields, _ := input.(map[string]any)
+
+	body := make(map[string]any, len(fields))
+	for name, value := range fields {
+		if key, ok := jsonKeys[name]; ok {
+			name = key
+		}
+		body[name] = value
+	}
+
+	return body
+}
+
+// create posts the input and resolves to the created document. idArgs
+// names further required Int arguments used to build the path.
+func create(typ graphql.Output, service string, input *graphql.InputObject, path func(graphql.ResolveParams) string, idArgs ...string) *graphql.Field {
+	args := graphql.FieldConfigArgument{
+		"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(input)},
+	}
+	for _, name := range idArgs {
+		args[name] = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}
+	}
+
+	return &graphql.Field{
+		Type: typ,
+		Args: args,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			var created document
+			err := stateFrom(p.Context).call(p.Context, service, http.MethodPost, path(p), payload(p.Args["input"]), &created)
+			return created, err
+		},
+	}
+}
+
+// update puts the input to path/{id} and resolves to the updated document,
+// since updates answer with a message only.
+func update(typ graphql.Output, service, path string, input *graphql.InputObject) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Args: graphql.FieldConfigArgument{
+			"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(input)},
+		},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			s := stateFrom(p.Context)
+			path := path + "/" + strconv.Itoa(p.Args["id"].(int))
+
+			if err := s.call(p.Context, service, http.MethodPut, path, payload(p.Args["input"]), nil); err != nil {
+				return nil, err
+			}
+
+			var updated document
+			err := s.get(p.Context, service, path, &updated)
+			return updated, err
+		},
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/4lerman/e_com/api/upstream"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// UpstreamsHandler godoc
+// @Summary Upstream circuit breakers and instances
+// @Description Get the circuit breaker state of every upstream service and the health and load of its instances
+// @Tags admin
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {array} upstream.BreakerStatus
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Router /admin/upstreams [get]
+func UpstreamsHandler(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, upstream.Breakers())
+}
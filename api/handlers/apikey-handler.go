@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/4lerman/e_com/api/apikey"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/gorilla/mux"
+)
+
+type APIKeyHandler struct {
+	store *apikey.Store
+}
+
+func NewAPIKeyHandler(store *apikey.Store) *APIKeyHandler {
+	return &APIKeyHandler{
+		store: store,
+	}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api-keys", h.CreateKey).Methods(http.MethodPost)
+	router.HandleFunc("/api-keys", h.ListKeys).Methods(http.MethodGet)
+	router.HandleFunc("/api-keys/{id:[0-9]+}/rotate", h.RotateKey).Methods(http.MethodPost)
+	router.HandleFunc("/api-keys/{id:[0-9]+}", h.RevokeKey).Methods(http.MethodDelete)
+}
+
+// CreateKey godoc
+// @Summary Create an API key
+// @Description Create an API key for a machine client. The key is only returned here; send it in the X-API-Key header.
+// @Tags admin
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param key body apikey.CreateKeyPayload true "Name, scopes and optional expiry of the key"
+// @Success 201 {object} apikey.IssuedKey
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var payload apikey.CreateKeyPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("expires_at must be in the future"))
+		return
+	}
+
+	key, err := h.store.CreateKey(r.Context(), payload)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJSON(w, http.StatusCreated, key)
+}
+
+// ListKeys godoc
+// @Summary List API keys
+// @Description List every API key with its scopes, expiry and last use, including revoked keys
+// @Tags admin
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {array} apikey.Key
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.store.ListKeys(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, keys)
+}
+
+// RotateKey godoc
+// @Summary Rotate an API key
+// @Description Replace an API key with a new one that keeps its name, scopes and expiry. The old key stops working at once.
+// @Tags admin
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "API key ID"
+// @Success 200 {object} apikey.IssuedKey
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /admin/api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	keyId, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	key, err := h.store.RotateKey(r.Context(), keyId)
+	if err != nil {
+		utils.WriteError(w, statusOf(err), err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJSON(w, http.StatusOK, key)
+}
+
+// RevokeKey godoc
+// @Summary Revoke an API key
+// @Description Disable an API key for good. It stays listed with its revocation time.
+// @Tags admin
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 204
+// @Failure 401 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	keyId, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	if err := h.store.RevokeKey(r.Context(), keyId); err != nil {
+		utils.WriteError(w, statusOf(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func statusOf(err error) int {
+	if errors.Is(err, apikey.ErrNotFound) {
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
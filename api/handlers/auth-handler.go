@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/4lerman/e_com/api/auth"
+	"github.com/4lerman/e_com/api/details"
+	"github.com/4lerman/e_com/api/upstream"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// account is the part of the user service's user the gateway needs to
+// issue tokens.
+type account struct {
+	ID       int    `json:"id"`
+	UserRole string `json:"user_role"`
+}
+
+// issuedSession is the user service's answer to opening or refreshing a
+// session.
+type issuedSession struct {
+	Session struct {
+		ID int `json:"id"`
+	} `json:"session"`
+	User         account `json:"user"`
+	RefreshToken string  `json:"refresh_token"`
+}
+
+type LoginPayload struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	// Device names the device in the user's list of sessions
+	Device string `json:"device,omitempty" validate:"omitempty,max=100"`
+}
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LoginHandler godoc
+// @Summary Log in
+// @Description Exchange email and password for an access and a refresh token, opening a session
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param credentials body handlers.LoginPayload true "User credentials"
+// @Success 200 {object} auth.TokenPair
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /auth/token [post]
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var payload LoginPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	credentials, _ := json.Marshal(map[string]string{"email": payload.Email, "password": payload.Password})
+	var user account
+	status, err := callUsers(r.Context(), http.MethodPost, "/users/credentials", credentials, &user)
+	if err != nil {
+		if status == http.StatusUnauthorized {
+			err = fmt.Errorf("invalid email or password")
+		}
+		writeFetchError(w, status, err)
+		return
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"user_id":    user.ID,
+		"device":     payload.Device,
+		"ip":         clientIP(r),
+		"user_agent": r.UserAgent(),
+	})
+	var session issuedSession
+	status, err = callUsers(r.Context(), http.MethodPost, "/sessions", body, &session)
+	if err != nil {
+		writeFetchError(w, status, err)
+		return
+	}
+
+	writeTokens(w, &session)
+}
+
+// RefreshTokenHandler godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access and refresh token. Each refresh token works once; using one again revokes its session.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param token body handlers.RefreshPayload true "Refresh token"
+// @Success 200 {object} auth.TokenPair
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /auth/refresh [post]
+func RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	// the user service reloads the user, so erased accounts and role
+	// changes take effect
+	body, _ := json.Marshal(map[string]string{
+		"refresh_token": payload.RefreshToken,
+		"ip":            clientIP(r),
+		"user_agent":    r.UserAgent(),
+	})
+	var session issuedSession
+	status, err := callUsers(r.Context(), http.MethodPost, "/sessions/refresh", body, &session)
+	if err != nil {
+		writeFetchError(w, status, err)
+		return
+	}
+
+	writeTokens(w, &session)
+}
+
+func writeTokens(w http.ResponseWriter, session *issuedSession) {
+	id := identity.Identity{UserID: session.User.ID, Role: session.User.UserRole}
+	tokens, err := auth.IssueTokens(id, session.Session.ID, session.RefreshToken)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// callUsers calls the user service and decodes its response into v, or
+// returns the status the gateway should answer with on failure. A zero
+// status means the call itself failed.
+func callUsers(ctx context.Context, method, path string, body []byte, v any) (int, error) {
+	users := upstream.For("users")
+	req, err := users.NewRequest(ctx, method, path, bytes.NewReader(body))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	resp, err := users.HTTPClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return http.StatusUnauthorized, fmt.Errorf("%s", details.ErrorMessage(resp))
+	case resp.StatusCode == http.StatusNotFound:
+		return http.StatusNotFound, fmt.Errorf("user not found")
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated:
+		return http.StatusBadGateway, fmt.Errorf("users service responded with %s", resp.Status)
+	}
+
+	if err := utils.ResParseJSON(resp, v); err != nil {
+		return http.StatusBadGateway, err
+	}
+
+	return http.StatusOK, nil
+}
+
+func writeFetchError(w http.ResponseWriter, status int, err error) {
+	if status == 0 {
+		upstream.WriteError(w, "users", err)
+		return
+	}
+
+	utils.WriteError(w, status, err)
+}
+
+// clientIP is the address the request came from.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+
+	return host
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/4lerman/e_com/api/auth"
+	"github.com/4lerman/e_com/api/details"
+	"github.com/4lerman/e_com/api/upstream"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/gorilla/mux"
+)
+
+// OrderDetailsHandler godoc
+// @Summary Get order details
+// @Description Get an order with its line items and their products, the customer and all payments in one call. Sections that could not be loaded are empty and listed in errors.
+// @Tags orders
+// @Security BearerAuth
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {object} details.OrderDetails
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 502 {object} Problem
+// @Router /orders/{id}/details [get]
+func OrderDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	orderId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+		return
+	}
+
+	caller, _ := auth.FromContext(r.Context())
+
+	d, err := details.Load(r.Context(), caller, orderId)
+
+	var statusErr *details.StatusError
+	switch {
+	case errors.As(err, &statusErr) && statusErr.Status < http.StatusInternalServerError:
+		utils.WriteError(w, statusErr.Status, fmt.Errorf("%s", statusErr.Message))
+		return
+	case errors.As(err, &statusErr):
+		utils.WriteError(w, http.StatusBadGateway, statusErr)
+		return
+	case err != nil:
+		upstream.WriteError(w, "orders", err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, d)
+}
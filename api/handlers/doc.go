@@ -0,0 +1,5 @@
+// Package handlers holds the gateway's own endpoints, plus the swagger
+// annotations of the routes it proxies to the domain services. Proxied
+// requests are forwarded by api/proxy using the route table in api/routes;
+// their annotated functions only exist so that swag can generate docs.
+package handlers
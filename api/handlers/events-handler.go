@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/4lerman/e_com/api/auth"
+	"github.com/4lerman/e_com/api/stream"
+	"github.com/4lerman/e_com/common/events"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/gorilla/websocket"
+)
+
+// EventsHandler godoc
+// @Summary Stream order and payment events
+// @Description Stream order status changes and payment creates and updates as they happen: as Server-Sent Events, or as JSON WebSocket messages when the request asks for a WebSocket upgrade. Clients get the events of their own orders; admins, API key clients and staff whose role holds order:read get those of all orders. Pass the ID of the last event received in the Last-Event-ID header or the last_event_id parameter to get the events missed since. Clients that cannot set headers may pass their token in access_token.
+// @Tags events
+// @Security BearerAuth
+// @Produce  text/event-stream
+// @Param order query int false "Only events of this order"
+// @Param last_event_id query int false "Resume after this event"
+// @Param Last-Event-ID header int false "Resume after this event"
+// @Param access_token query string false "Access token, for clients that cannot set the Authorization header"
+// @Success 200 {object} events.Event
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /events [get]
+func EventsHandler(broker *stream.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := auth.FromContext(r.Context())
+
+		var filter events.Filter
+		if caller.IsRestricted() {
+			filter.UserID = caller.UserID
+		}
+
+		query := r.URL.Query()
+		if order := query.Get("order"); order != "" {
+			orderId, err := strconv.Atoi(order)
+			if err != nil || orderId <= 0 {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid order id"))
+				return
+			}
+			filter.OrderID = orderId
+		}
+
+		lastEventId := r.Header.Get("Last-Event-ID")
+		if lastEventId == "" {
+			lastEventId = query.Get("last_event_id")
+		}
+
+		var after int64
+		if lastEventId != "" {
+			id, err := strconv.ParseInt(lastEventId, 10, 64)
+			if err != nil || id < 0 {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid last event id"))
+				return
+			}
+			after = id
+		}
+
+		serve := broker.ServeSSE
+		if websocket.IsWebSocketUpgrade(r) {
+			serve = broker.ServeWebSocket
+		}
+
+		err := serve(w, r, filter, after)
+		switch {
+		case err == nil:
+		case errors.Is(err, stream.ErrClosed):
+			logger.FromContext(r.Context()).Info("event stream closed by the gateway", "user_id", caller.UserID)
+		default:
+			logger.FromContext(r.Context()).Warn("event stream failed", "user_id", caller.UserID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/4lerman/e_com/api/auth"
+	"github.com/4lerman/e_com/api/graph"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// GraphQLHandler serves /graphql: users, products, orders and payments
+// with nested data in one round trip, plus the main create and update
+// mutations. POST takes a JSON graph.Request; GET takes the query in the
+// query string and cannot run mutations.
+func GraphQLHandler(schema *graph.Schema) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req graph.Request
+
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			req.Query = query.Get("query")
+			req.OperationName = query.Get("operationName")
+			if variables := query.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid variables: %v", err))
+					return
+				}
+			}
+
+			if graph.HasMutation(req.Query) {
+				utils.WriteError(w, http.StatusMethodNotAllowed, fmt.Errorf("mutations must be sent with POST"))
+				return
+			}
+		default:
+			if err := utils.ReqParseJSON(r, &req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		if req.Query == "" {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("query is required"))
+			return
+		}
+
+		caller, _ := auth.FromContext(r.Context())
+
+		utils.WriteJSON(w, http.StatusOK, schema.Execute(r.Context(), caller, req))
+	}
+}
@@ -1,228 +1,132 @@
 package handlers
 
-import (
-	"net/http"
-
-	configs "github.com/4lerman/e_com/common/config"
-	"github.com/4lerman/e_com/common/utils"
-	"github.com/gorilla/mux"
-)
-
-var orderServiceURL = configs.Envs.Orders_Url + "/orders"
-
 // GetOrdersHandler godoc
 // @Summary Get all orders
 // @Description Get details of all orders
 // @Tags orders
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Success 200 {array} types.Order
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} Problem
 // @Router /orders [get]
-func GetOrdersHandler(w http.ResponseWriter, r *http.Request) {
-    req, err := http.NewRequest(http.MethodGet, orderServiceURL, nil)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func GetOrdersHandler() {}
 
 // CreateOrderHandler godoc
 // @Summary Create a new order
-// @Description Create a new order
+// @Description Create a new order. The user it is for must have verified their email. The order keeps a copy of the shipping and billing addresses picked from the user's address book, the defaults unless shipping_address_id or billing_address_id say otherwise. Orders start out with status new and a total of 0; the total follows the items added.
 // @Tags orders
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param order body types.CreateOrderPayload true "Order payload"
+// @Param Idempotency-Key header string false "Client key that makes retries of this request safe"
 // @Success 201 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 201 {string} Location "Path of the created resource"
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem "The user's email is not verified"
+// @Failure 409 {object} Problem "A request with the same key is still being processed"
+// @Failure 422 {object} Problem "The key was used for a different request"
+// @Failure 500 {object} Problem
 // @Router /orders [post]
-func CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
-    req, err := http.NewRequest(http.MethodPost, orderServiceURL, r.Body)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func CreateOrderHandler() {}
 
 // GetOrdersByQueryHandler godoc
 // @Summary Get orders by query
 // @Description Get orders by status or user
 // @Tags orders
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param status query string false "Order status"
 // @Param user query int false "User ID"
 // @Success 200 {array} types.Order
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /orders/search [get]
-func GetOrdersByQueryHandler(w http.ResponseWriter, r *http.Request) {
-    queryParams := r.URL.RawQuery
-    url := orderServiceURL + "/search?" + queryParams
-
-    req, err := http.NewRequest(http.MethodGet, url, nil)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func GetOrdersByQueryHandler() {}
 
 // GetOrderByIDHandler godoc
 // @Summary Get order by ID
 // @Description Get order details by ID
 // @Tags orders
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Order ID"
 // @Success 200 {object} types.Order
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 404 {object} Problem
 // @Router /orders/{id} [get]
-func GetOrderByIDHandler(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    orderID := vars["id"]
-
-    url := orderServiceURL + "/" + orderID
-
-    req, err := http.NewRequest(http.MethodGet, url, nil)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func GetOrderByIDHandler() {}
 
 // UpdateOrderHandler godoc
 // @Summary Update an order
-// @Description Update an existing order
+// @Description Update an existing order; the fields left out keep their values. Clients cannot change the status, total or user of their orders.
 // @Tags orders
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Order ID"
 // @Param order body types.UpdateOrderPayload true "Order payload"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem "Clients cannot set these fields"
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /orders/{id} [put]
-func UpdateOrderHandler(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    orderID := vars["id"]
-
-    url := orderServiceURL + "/" + orderID
-
-    req, err := http.NewRequest(http.MethodPut, url, r.Body)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func UpdateOrderHandler() {}
 
 // DeleteOrderHandler godoc
 // @Summary Delete an order
 // @Description Delete an order by ID
 // @Tags orders
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Order ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 500 {object} Problem
 // @Router /orders/{id} [delete]
-func DeleteOrderHandler(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    orderID := vars["id"]
-
-    url := orderServiceURL + "/" + orderID
-
-    req, err := http.NewRequest(http.MethodDelete, url, nil)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func DeleteOrderHandler() {}
 
 // CreateOrderItemHandler godoc
 // @Summary Create an order item
 // @Description Create a new order item for an order
 // @Tags orders
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Order ID"
 // @Param orderItem body types.CreateOrderItemPayload true "Order item payload"
+// @Param Idempotency-Key header string false "Client key that makes retries of this request safe"
 // @Success 201 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 201 {string} Location "Path of the created resource"
+// @Failure 409 {object} Problem "A request with the same key is still being processed"
+// @Failure 422 {object} Problem "The key was used for a different request"
+// @Failure 500 {object} Problem
 // @Router /orders/{id}/order [post]
-func CreateOrderItemHandler(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    orderID := vars["id"]
+func CreateOrderItemHandler() {}
 
-    url := orderServiceURL + "/" + orderID + "/order"
-    req, err := http.NewRequest(http.MethodPost, url, r.Body)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+// GetOrderItemsHandler godoc
+// @Summary Get order items
+// @Description Get the line items of an order
+// @Tags orders
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {array} types.OrderItem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /orders/{id}/items [get]
+func GetOrderItemsHandler() {}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/4lerman/e_com/api/auth"
+	"github.com/4lerman/e_com/api/details"
+	"github.com/4lerman/e_com/api/privacy"
+	"github.com/4lerman/e_com/api/upstream"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/gorilla/mux"
+)
+
+// ExportUserHandler godoc
+// @Summary Export user data
+// @Description Download everything held about a user: profile, address book, orders with their items and payments. Clients export their own data; every export is logged. With format=zip the archive is a zip of one JSON file per section.
+// @Tags users
+// @Security BearerAuth
+// @Produce  json
+// @Produce  application/zip
+// @Param id path int true "User ID"
+// @Param format query string false "Archive format" Enums(json, zip)
+// @Success 200 {object} privacy.Archive
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 502 {object} Problem
+// @Router /users/{id}/export [get]
+func ExportUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("format must be json or zip"))
+		return
+	}
+
+	caller, _ := auth.FromContext(r.Context())
+
+	archive, err := privacy.Export(r.Context(), caller, userId)
+
+	var statusErr *details.StatusError
+	switch {
+	case errors.As(err, &statusErr) && statusErr.Status < http.StatusInternalServerError:
+		utils.WriteError(w, statusErr.Status, fmt.Errorf("%s", statusErr.Message))
+		return
+	case errors.As(err, &statusErr):
+		utils.WriteError(w, http.StatusBadGateway, statusErr)
+		return
+	case err != nil:
+		upstream.WriteError(w, "users", err)
+		return
+	}
+
+	name := fmt.Sprintf("user-%d-%s", userId, archive.ExportedAt.Format("20060102T150405Z"))
+
+	if format != "zip" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".json"))
+		utils.WriteJSON(w, http.StatusOK, archive)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".zip"))
+	w.WriteHeader(http.StatusOK)
+
+	// the status is out already, a broken archive is all that is left
+	if err := archive.WriteZip(w); err != nil {
+		logger.FromContext(r.Context()).Error("failed to write data export", "user_id", userId, "error", err)
+	}
+}
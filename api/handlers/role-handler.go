@@ -0,0 +1,130 @@
+package handlers
+
+// ListRolesHandler godoc
+// @Summary List roles
+// @Description Get every role with its permissions. admin and client are built in; admins hold every permission, and clients manage their own account, orders and payments.
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {array} types.Role
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /roles [get]
+func ListRolesHandler() {}
+
+// CreateRoleHandler godoc
+// @Summary Create a role
+// @Description Define a staff role with permissions from the catalog. Its users may do what the permissions allow on every record.
+// @Tags roles
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param role body types.CreateRolePayload true "Role to create"
+// @Success 201 {object} map[string]string
+// @Header 201 {string} Location "Path of the created role"
+// @Failure 400 {object} Problem
+// @Failure 409 {object} Problem "The role already exists"
+// @Failure 500 {object} Problem
+// @Router /roles [post]
+func CreateRoleHandler() {}
+
+// GetRoleHandler godoc
+// @Summary Get a role
+// @Description Get a role with its permissions
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Param name path string true "Role name"
+// @Success 200 {object} types.Role
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /roles/{name} [get]
+func GetRoleHandler() {}
+
+// UpdateRoleHandler godoc
+// @Summary Update a role
+// @Description Replace the description and permissions of a role. Built-in roles cannot be changed.
+// @Tags roles
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param name path string true "Role name"
+// @Param role body types.UpdateRolePayload true "Description and permissions"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /roles/{name} [put]
+func UpdateRoleHandler() {}
+
+// DeleteRoleHandler godoc
+// @Summary Delete a role
+// @Description Delete a role nobody has. Built-in roles cannot be deleted.
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Param name path string true "Role name"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem "The role is assigned to users"
+// @Failure 500 {object} Problem
+// @Router /roles/{name} [delete]
+func DeleteRoleHandler() {}
+
+// AddRolePermissionHandler godoc
+// @Summary Attach a permission
+// @Description Give a role a permission from the catalog, such as product:update
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Param name path string true "Role name"
+// @Param permission path string true "Permission"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /roles/{name}/permissions/{permission} [put]
+func AddRolePermissionHandler() {}
+
+// RemoveRolePermissionHandler godoc
+// @Summary Detach a permission
+// @Description Take a permission from a role
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Param name path string true "Role name"
+// @Param permission path string true "Permission"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /roles/{name}/permissions/{permission} [delete]
+func RemoveRolePermissionHandler() {}
+
+// ListPermissionsHandler godoc
+// @Summary List permissions
+// @Description Get the catalog of permissions roles can hold, named resource:action
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {array} types.Permission
+// @Failure 500 {object} Problem
+// @Router /permissions [get]
+func ListPermissionsHandler() {}
+
+// CheckPermissionHandler godoc
+// @Summary Check a permission
+// @Description Whether a role holds a permission. The gateway asks this for every request it lets through by a permission.
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Param role query string true "Role name"
+// @Param permission query string true "Permission"
+// @Success 200 {object} types.PermissionCheck
+// @Failure 400 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /permissions/check [get]
+func CheckPermissionHandler() {}
@@ -1,177 +1,319 @@
 package handlers
 
-import (
-    "net/http"
-    "github.com/4lerman/e_com/common/config"
-    "github.com/4lerman/e_com/common/utils"
-    "github.com/gorilla/mux"
-)
-
-var userServiceURL = configs.Envs.Users_Url + "/users"
-
 // GetUsersHandler godoc
 // @Summary List all users
 // @Description Get all users from the user service
 // @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Produce  json
 // @Success 200 {array} types.User
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} Problem
 // @Router /users [get]
-func GetUsersHandler(w http.ResponseWriter, r *http.Request) {
-    req, err := http.NewRequest(http.MethodGet, userServiceURL, nil)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func GetUsersHandler() {}
 
 // CreateUserHandler godoc
 // @Summary Create a new user
 // @Description Create a new user in the user service
 // @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param user body types.CreateUserPayload true "User to create"
 // @Success 201 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 201 {string} Location "Path of the created resource"
+// @Failure 409 {object} Problem "The email is already registered"
+// @Failure 500 {object} Problem
 // @Router /users [post]
-func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
-    req, err := http.NewRequest(http.MethodPost, userServiceURL, r.Body)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func CreateUserHandler() {}
 
 // GetUserByQueryHandler godoc
 // @Summary Get users by query
 // @Description Get users by name or email from the user service
 // @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Produce  json
 // @Param name query string false "User name"
 // @Param email query string false "User email"
 // @Success 200 {array} types.User
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} Problem
 // @Router /users/search [get]
-func GetUserByQueryHandler(w http.ResponseWriter, r *http.Request) {
-    queryParams := r.URL.RawQuery
-    userServiceURL = userServiceURL + "/search?" + queryParams
-
-    req, err := http.NewRequest(http.MethodGet, userServiceURL, nil)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func GetUserByQueryHandler() {}
 
 // GetUserByIDHandler godoc
 // @Summary Get user by ID
 // @Description Get a user by ID from the user service
 // @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Produce  json
 // @Param id path int true "User ID"
 // @Success 200 {object} types.User
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} Problem
 // @Router /users/{id} [get]
-func GetUserByIDHandler(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    userID := vars["id"]
-    userServiceURL = userServiceURL + "/" + userID
-
-    req, err := http.NewRequest(http.MethodGet, userServiceURL, nil)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func GetUserByIDHandler() {}
 
 // UpdateUserHandler godoc
 // @Summary Update a user
 // @Description Update a user in the user service
 // @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param id path int true "User ID"
 // @Param user body types.UpdateUserPayload true "User to update"
 // @Success 200 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} Problem
 // @Router /users/{id} [put]
-func UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    userID := vars["id"]
-    userServiceURL = userServiceURL + "/" + userID
-
-    req, err := http.NewRequest(http.MethodPut, userServiceURL, r.Body)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func UpdateUserHandler() {}
 
 // DeleteUserHandler godoc
-// @Summary Delete a user
-// @Description Delete a user in the user service
+// @Summary Erase a user
+// @Description Erase the personal data of a user. The account is kept anonymised so its orders and payments stay intact; its address book is deleted and its sessions are revoked. Clients can only erase their own. Every erasure is logged.
 // @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Param id path int true "User ID"
 // @Success 200 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 409 {object} Problem "The user is erased already"
+// @Failure 500 {object} Problem
 // @Router /users/{id} [delete]
-func DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    userID := vars["id"]
-    userServiceURL = userServiceURL + "/" + userID
-
-    req, err := http.NewRequest(http.MethodDelete, userServiceURL, nil)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, err)
-        return
-    }
-    defer resp.Body.Close()
-    utils.ResCopy(w, resp.StatusCode, resp)
-}
+func DeleteUserHandler() {}
+
+// AssignRoleHandler godoc
+// @Summary Assign a role
+// @Description Give a user a role. Admins only; the user gets it with their next token.
+// @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param role body types.AssignRolePayload true "Role to assign"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /users/{id}/role [put]
+func AssignRoleHandler() {}
+
+// ListDataRequestsHandler godoc
+// @Summary List a user's data requests
+// @Description Get the compliance log of the exports and erasures of a user's data. Admins only.
+// @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {array} types.DataRequest
+// @Failure 403 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /users/{id}/data-requests [get]
+func ListDataRequestsHandler() {}
+
+// ListAddressesHandler godoc
+// @Summary List a user's addresses
+// @Description Get the address book of a user. Clients can only read their own.
+// @Tags addresses
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {array} types.Address
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/addresses [get]
+func ListAddressesHandler() {}
+
+// CreateAddressHandler godoc
+// @Summary Add an address
+// @Description Add an address to a user's book. The first one becomes the default shipping and billing address; setting default_shipping or default_billing moves that default to the new address.
+// @Tags addresses
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param address body types.AddressPayload true "Address to add"
+// @Success 201 {object} map[string]string
+// @Header 201 {string} Location "Path of the created address"
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/addresses [post]
+func CreateAddressHandler() {}
+
+// GetAddressHandler godoc
+// @Summary Get an address
+// @Tags addresses
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param addressId path int true "Address ID"
+// @Success 200 {object} types.Address
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/addresses/{addressId} [get]
+func GetAddressHandler() {}
+
+// UpdateAddressHandler godoc
+// @Summary Replace an address
+// @Description Replace an address. Setting default_shipping or default_billing moves that default to it; leaving them unset keeps the defaults where they are.
+// @Tags addresses
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param addressId path int true "Address ID"
+// @Param address body types.AddressPayload true "New address"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/addresses/{addressId} [put]
+func UpdateAddressHandler() {}
+
+// DeleteAddressHandler godoc
+// @Summary Delete an address
+// @Description Delete an address. The defaults it held pass to the user's oldest remaining address. Orders keep their copy of it.
+// @Tags addresses
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param addressId path int true "Address ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/addresses/{addressId} [delete]
+func DeleteAddressHandler() {}
+
+// RegisterHandler godoc
+// @Summary Register
+// @Description Sign up with a password. Self-registered accounts always get the client role; log in at /auth/token afterwards. A link to verify the email is sent to it, and orders are taken once it is verified.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param user body types.RegisterPayload true "Account to create"
+// @Success 201 {object} map[string]string
+// @Header 201 {string} Location "Path of the created user"
+// @Failure 400 {object} Problem
+// @Failure 409 {object} Problem "The email is already registered"
+// @Router /users/register [post]
+func RegisterHandler() {}
+
+// VerifyEmailHandler godoc
+// @Summary Verify an email
+// @Description Confirm the email of an account with the token from the verification link.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param token body types.VerifyEmailPayload true "Verification token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Router /users/verify-email [post]
+func VerifyEmailHandler() {}
+
+// ResendVerificationHandler godoc
+// @Summary Resend the email verification
+// @Description Send a new verification link to the email unless it is verified already. The answer is the same whether the email is registered or not.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param email body types.ResendVerificationPayload true "Email of the account"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Router /users/verify-email/resend [post]
+func ResendVerificationHandler() {}
+
+// ChangePasswordHandler godoc
+// @Summary Change a password
+// @Description Change the password of a user. Users changing their own password must send the current one; admins may leave it out. Every other session of the user is revoked.
+// @Tags users
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param password body types.ChangePasswordPayload true "Current and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/password [put]
+func ChangePasswordHandler() {}
+
+// RequestPasswordResetHandler godoc
+// @Summary Request a password reset
+// @Description Send a single-use, time-limited password reset token to the email. The answer is the same whether the email is registered or not.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param email body types.PasswordResetPayload true "Email of the account"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Router /users/password-reset [post]
+func RequestPasswordResetHandler() {}
+
+// ConfirmPasswordResetHandler godoc
+// @Summary Reset a password
+// @Description Set a new password with a reset token. The token, and every other pending one of the account, cannot be used again, and every session of the account is revoked.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param reset body types.ConfirmPasswordResetPayload true "Reset token and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} Problem
+// @Router /users/password-reset/confirm [post]
+func ConfirmPasswordResetHandler() {}
+
+// ListSessionsHandler godoc
+// @Summary List a user's sessions
+// @Description Get the active sessions of a user, most recently seen first, with the device, IP and user agent they were last refreshed from. Clients can only read their own.
+// @Tags sessions
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {array} types.Session
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/sessions [get]
+func ListSessionsHandler() {}
+
+// RevokeSessionsHandler godoc
+// @Summary Revoke all of a user's sessions
+// @Description Log a user out everywhere. Access tokens of the sessions stop working at once and their refresh tokens are no longer accepted.
+// @Tags sessions
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/sessions [delete]
+func RevokeSessionsHandler() {}
+
+// RevokeSessionHandler godoc
+// @Summary Revoke a session
+// @Description Log a user out of one session. Its access tokens stop working at once and its refresh token is no longer accepted.
+// @Tags sessions
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param sessionId path int true "Session ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /users/{id}/sessions/{sessionId} [delete]
+func RevokeSessionHandler() {}
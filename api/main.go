@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,36 +10,81 @@ import (
 	"time"
 
 	"github.com/4lerman/e_com/api/routes"
-	"github.com/gorilla/mux"
+	"github.com/4lerman/e_com/api/stream"
 	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/db"
+	"github.com/4lerman/e_com/common/events"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/metrics"
+	"github.com/4lerman/e_com/common/tracing"
+	"github.com/gorilla/mux"
 
 	_ "github.com/4lerman/e_com/docs"
 )
 
-
 // @title E-commerce Service
 // @version 1.0
 // @description This is a API server for E-commerce service.
+// @description Every route is also served under /api/v2, where responses are wrapped in {data, error, meta} and creates answer with the created resource.
 // @host e-comm-hl.onrender.com
 // @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
+	logger.Init("api")
+
+	if err := configs.CheckSecret("JWT_SECRET", configs.Envs.JWT_Secret); err != nil {
+		logger.Fatal("invalid configuration", err)
+	}
+	if err := configs.CheckSecret("INTERNAL_SECRET", configs.Envs.Internal_Secret); err != nil {
+		logger.Fatal("invalid configuration", err)
+	}
+
+	shutdownTracing, err := tracing.Init("api")
+	if err != nil {
+		logger.Fatal("tracing setup failed", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	db, err := db.InitStorage()
+	if err != nil {
+		logger.Fatal("db connection failed", err)
+	}
+
+	slog.Info("db connected")
+	metrics.RegisterDB(db, "api")
+
+	eventStore := events.NewStore(db)
+	go eventStore.PurgeEvery(time.Duration(configs.Envs.Event_Retention)*time.Second, time.Hour)
+
+	broker := stream.NewBroker(eventStore)
+
 	router := mux.NewRouter()
-	routes.Routes(router)
+	router.Use(tracing.Middleware, logger.Middleware, metrics.Middleware)
+	if err := routes.Routes(router, db, broker); err != nil {
+		logger.Fatal("gateway setup failed", err)
+	}
 
 	port := configs.Envs.API_Port
 	server := &http.Server{
-		Addr:   ":" + port,
+		Addr:    ":" + port,
 		Handler: router,
 	}
+	// open event streams would otherwise hold up the shutdown
+	server.RegisterOnShutdown(broker.Close)
 
 	go gracefulShutdown(server)
 
-	log.Printf("Server(API) is starting on port %s\n", port)
+	slog.Info("server is starting", "port", port)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server startup failed: %v\n", err)
+		logger.Fatal("server startup failed", err)
 	}
 
-	log.Println("Server(API) gracefully stopped")
+	slog.Info("server gracefully stopped")
 }
 
 func gracefulShutdown(server *http.Server) {
@@ -52,6 +97,6 @@ func gracefulShutdown(server *http.Server) {
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Graceful shutdown failed: %v\n", err)
+		logger.Fatal("graceful shutdown failed", err)
 	}
 }
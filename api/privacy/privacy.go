@@ -0,0 +1,113 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/4lerman/e_com/api/details"
+	"github.com/4lerman/e_com/common/identity"
+)
+
+// Archive is everything the services hold about a user, as handed out on
+// a data export request. The documents are passed on as the services
+// return them.
+type Archive struct {
+	ExportedAt time.Time         `json:"exported_at"`
+	Profile    json.RawMessage   `json:"profile" swaggertype:"object"`
+	Addresses  json.RawMessage   `json:"addresses" swaggertype:"array,object"`
+	Orders     []Order           `json:"orders"`
+	Payments   []json.RawMessage `json:"payments" swaggertype:"array,object"`
+}
+
+// Order is an order of the user with its line items.
+type Order struct {
+	Order json.RawMessage   `json:"order" swaggertype:"object"`
+	Items []json.RawMessage `json:"items" swaggertype:"array,object"`
+}
+
+type personalData struct {
+	Profile   json.RawMessage `json:"profile"`
+	Addresses json.RawMessage `json:"addresses"`
+}
+
+type order struct {
+	ID int `json:"id"`
+}
+
+// Export collects the personal data of the user, then their orders with
+// items and their payments, on behalf of the caller. Unlike order details
+// an export must be complete, so any failure fails it. The user service
+// logs the export.
+func Export(ctx context.Context, caller identity.Identity, userId int) (*Archive, error) {
+	a := &Archive{
+		ExportedAt: time.Now().UTC(),
+		Orders:     []Order{},
+		Payments:   []json.RawMessage{},
+	}
+
+	var data personalData
+	if err := details.Get(ctx, caller, "users", "/users/"+strconv.Itoa(userId)+"/export", &data); err != nil {
+		return nil, err
+	}
+	a.Profile = data.Profile
+	a.Addresses = data.Addresses
+
+	var orders []json.RawMessage
+	if err := details.Get(ctx, caller, "orders", "/orders/search?user="+strconv.Itoa(userId), &orders); err != nil {
+		return nil, err
+	}
+
+	for _, raw := range orders {
+		var o order
+		if err := json.Unmarshal(raw, &o); err != nil {
+			return nil, err
+		}
+
+		items := []json.RawMessage{}
+		if err := details.Get(ctx, caller, "orders", "/orders/"+strconv.Itoa(o.ID)+"/items", &items); err != nil {
+			return nil, err
+		}
+
+		a.Orders = append(a.Orders, Order{Order: raw, Items: items})
+	}
+
+	if err := details.Get(ctx, caller, "payments", "/payments/search?user="+strconv.Itoa(userId), &a.Payments); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// WriteZip writes the archive as a zip with one JSON file per section.
+func (a *Archive) WriteZip(w io.Writer) error {
+	z := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"profile.json", a.Profile},
+		{"addresses.json", a.Addresses},
+		{"orders.json", a.Orders},
+		{"payments.json", a.Payments},
+	}
+
+	for _, file := range files {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: a.ExportedAt})
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.v); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/4lerman/e_com/api/upstream"
+)
+
+// Route maps a gateway path prefix onto a path of an upstream service,
+// e.g. /api/v1/users/... onto http://user-service:5001/users/...
+// Upstream is the service's URL unless the upstreams file lists instances.
+type Route struct {
+	Name     string
+	Prefix   string
+	Upstream string
+	Path     string
+}
+
+// New builds a reverse proxy that forwards method, path, query, headers and
+// body of every request under route.Prefix to the route's upstream as-is;
+// client sends each attempt to one of the upstream's instances.
+func New(route Route, client *upstream.Client) *httputil.ReverseProxy {
+	target := client.Target()
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Path = route.Path + strings.TrimPrefix(pr.In.URL.Path, route.Prefix)
+			pr.Out.URL.RawPath = ""
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+		// point the Location of created resources at the gateway
+		ModifyResponse: func(resp *http.Response) error {
+			location := resp.Header.Get("Location")
+			if location == route.Path || strings.HasPrefix(location, route.Path+"/") {
+				resp.Header.Set("Location", route.Prefix+strings.TrimPrefix(location, route.Path))
+			}
+			return nil
+		},
+		Transport: client,
+		// flush every write so streamed upstream responses reach the client immediately
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			upstream.WriteError(w, route.Name, err)
+		},
+	}
+}
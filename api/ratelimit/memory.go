@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket has refilled to its burst
+	full time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{
+		buckets: make(map[string]*bucket),
+	}
+
+	go s.evictIdle(time.Minute)
+
+	return s
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	var res Result
+	b.tokens, res = take(b.tokens, b.last, now, limit)
+	b.last = now
+	b.full = now.Add(res.ResetAfter)
+
+	return res, nil
+}
+
+// evictIdle drops the buckets that refilled, every interval.
+func (s *MemoryStore) evictIdle(interval time.Duration) {
+	for now := range time.Tick(interval) {
+		s.evict(now)
+	}
+}
+
+// evict drops the buckets that are full by now. A new bucket starts out
+// full, so dropping them changes nothing, while a bucket of a slow limit
+// is kept until it refilled, however long it sits unused.
+func (s *MemoryStore) evict(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreEvict(t *testing.T) {
+	// one request every 20 seconds, in bursts of 5: a drained bucket takes
+	// 100 seconds to refill, longer than the eviction interval
+	limit := Limit{Rate: 0.05, Burst: 5}
+
+	tests := []struct {
+		name  string
+		used  int
+		idle  time.Duration
+		evict bool
+	}{
+		{"unused bucket", 0, 0, true},
+		{"drained bucket after a minute", 5, time.Minute, false},
+		{"drained bucket just before it refilled", 5, 100*time.Second - time.Millisecond, false},
+		{"drained bucket once refilled", 5, 100 * time.Second, true},
+		{"partly used bucket once refilled", 2, 40 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &MemoryStore{buckets: make(map[string]*bucket)}
+
+			start := time.Now()
+			s.buckets["client"] = &bucket{tokens: float64(limit.Burst), last: start, full: start}
+			for i := 0; i < tt.used; i++ {
+				if _, err := s.Allow(context.Background(), "client", limit); err != nil {
+					t.Fatalf("Allow() error = %v", err)
+				}
+			}
+
+			s.evict(s.buckets["client"].last.Add(tt.idle))
+
+			if _, kept := s.buckets["client"]; kept == tt.evict {
+				t.Errorf("bucket kept = %v, want %v", kept, !tt.evict)
+			}
+		})
+	}
+}
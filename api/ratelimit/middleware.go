@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/4lerman/e_com/api/auth"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// Middleware limits requests of the route group per client. Clients are
+// told apart by the API key or user Authenticate verified, else by IP
+// address.
+func Middleware(store Store, group string, limit Limit, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := store.Allow(r.Context(), "ratelimit:"+group+":"+clientKey(r), limit)
+		if err != nil {
+			// a broken limiter backend should not take the whole API down
+			logger.FromContext(r.Context()).Error("rate limiter unavailable, letting request through", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("X-RateLimit-Reset", ceilSeconds(res.ResetAfter))
+
+		if !res.Allowed {
+			w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+			utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit exceeded, retry in %s seconds", ceilSeconds(res.RetryAfter)))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey only trusts credentials Authenticate verified. Keys sent to
+// routes without authentication, such as the login, count as the IP, or a
+// made-up key would get a fresh bucket with every request.
+func clientKey(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok {
+		if id.IsService() {
+			return "key:" + strconv.Itoa(id.KeyID)
+		}
+
+		return "user:" + strconv.Itoa(id.UserID)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
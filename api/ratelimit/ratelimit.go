@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit is a token bucket: it refills at Rate tokens per second and holds at
+// most Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// Store keeps token buckets. The in-memory store is local to one gateway,
+// the redis store lets several replicas enforce one quota.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// ParseLimit parses "rate:burst", e.g. "10:20" for 10 requests per second
+// with bursts of up to 20.
+func ParseLimit(s string) (Limit, error) {
+	rate, burst, ok := strings.Cut(s, ":")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected rate:burst", s)
+	}
+
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate in rate limit %q", s)
+	}
+
+	b, err := strconv.Atoi(burst)
+	if err != nil || b <= 0 {
+		return Limit{}, fmt.Errorf("invalid burst in rate limit %q", s)
+	}
+
+	return Limit{Rate: r, Burst: b}, nil
+}
+
+// NewStore returns the backend selected by RATE_LIMIT_BACKEND.
+func NewStore() (Store, error) {
+	switch configs.Envs.Rate_Limit_Backend {
+	case "memory", "":
+		return NewMemoryStore(), nil
+	case "redis":
+		opts, err := redis.ParseURL(configs.Envs.Redis_Url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+
+		return NewRedisStore(redis.NewClient(opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", configs.Envs.Rate_Limit_Backend)
+	}
+}
+
+// take refills a bucket holding tokens since last and takes one token from it.
+func take(tokens float64, last, now time.Time, limit Limit) (float64, Result) {
+	tokens += now.Sub(last).Seconds() * limit.Rate
+	if tokens > float64(limit.Burst) {
+		tokens = float64(limit.Burst)
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	return tokens, newResult(allowed, tokens, limit)
+}
+
+// newResult describes a bucket left with tokens after a request.
+func newResult(allowed bool, tokens float64, limit Limit) Result {
+	res := Result{
+		Allowed:    allowed,
+		Remaining:  int(tokens),
+		ResetAfter: seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
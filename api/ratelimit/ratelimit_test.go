@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{"10:20", Limit{Rate: 10, Burst: 20}, false},
+		{"0.5:3", Limit{Rate: 0.5, Burst: 3}, false},
+		{"10", Limit{}, true},
+		{"0:20", Limit{}, true},
+		{"-1:20", Limit{}, true},
+		{"10:0", Limit{}, true},
+		{"ten:20", Limit{}, true},
+		{"10:1.5", Limit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTake(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 4}
+	start := time.Now()
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{"full bucket", 4, 0, true, 3, 0},
+		{"last token", 1, 0, true, 0, 0},
+		{"empty bucket", 0, 0, false, 0, 500 * time.Millisecond},
+		{"half a token", 0.5, 0, false, 0, 250 * time.Millisecond},
+		{"refilled by time", 0, time.Second, true, 1, 0},
+		{"refill stops at the burst", 1, time.Hour, true, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, res := take(tt.tokens, start, start.Add(tt.elapsed), limit)
+
+			if res.Allowed != tt.allowed || res.Remaining != tt.remaining || res.RetryAfter != tt.retryAfter {
+				t.Errorf("take() = %+v, want allowed %v, remaining %d, retry after %s", res, tt.allowed, tt.remaining, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreAllow(t *testing.T) {
+	s := &MemoryStore{buckets: make(map[string]*bucket)}
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if res, _ := s.Allow(context.Background(), "a", limit); !res.Allowed {
+			t.Fatalf("request %d denied within the burst", i+1)
+		}
+	}
+
+	if res, _ := s.Allow(context.Background(), "a", limit); res.Allowed {
+		t.Errorf("request beyond the burst allowed")
+	}
+
+	if res, _ := s.Allow(context.Background(), "b", limit); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("another client's first request = %+v, want allowed with 2 remaining", res)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	s := &MemoryStore{buckets: make(map[string]*bucket)}
+	handler := Middleware(s, "products", Limit{Rate: 0.001, Burst: 2}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name   string
+		addr   string
+		apiKey string
+		want   int
+		left   string
+	}{
+		{"first request", "10.0.0.1:1000", "", http.StatusOK, "1"},
+		{"unverified API key counts as the IP", "10.0.0.1:2000", "made-up", http.StatusOK, "0"},
+		{"over the limit", "10.0.0.1:3000", "", http.StatusTooManyRequests, "0"},
+		{"another IP", "10.0.0.2:1000", "", http.StatusOK, "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+			r.RemoteAddr = tt.addr
+			if tt.apiKey != "" {
+				r.Header.Set("X-API-Key", tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if left := w.Header().Get("X-RateLimit-Remaining"); left != tt.left {
+				t.Errorf("X-RateLimit-Remaining = %s, want %s", left, tt.left)
+			}
+			if tt.want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Errorf("Retry-After is not set")
+			}
+		})
+	}
+}
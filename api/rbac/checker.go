@@ -0,0 +1,99 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/4lerman/e_com/api/details"
+	"github.com/4lerman/e_com/api/upstream"
+)
+
+// Checker asks the user service's permission check API whether roles hold
+// permissions, and remembers the answers for a while, so role changes take
+// effect at the gateway within the TTL.
+type Checker struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	answers map[key]answer
+}
+
+type key struct {
+	role       string
+	permission string
+}
+
+type answer struct {
+	allowed bool
+	expires time.Time
+}
+
+type check struct {
+	Allowed bool `json:"allowed"`
+}
+
+func NewChecker(ttl time.Duration) *Checker {
+	return &Checker{
+		ttl:     ttl,
+		answers: map[key]answer{},
+	}
+}
+
+func (c *Checker) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	k := key{role: role, permission: permission}
+
+	c.mu.Lock()
+	a, ok := c.answers[k]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(a.expires) {
+		return a.allowed, nil
+	}
+
+	allowed, err := ask(ctx, role, permission)
+	if err != nil {
+		return false, err
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		c.answers[k] = answer{allowed: allowed, expires: time.Now().Add(c.ttl)}
+		c.mu.Unlock()
+	}
+
+	return allowed, nil
+}
+
+// ask calls the user service as the gateway itself, not on behalf of the
+// caller.
+func ask(ctx context.Context, role, permission string) (bool, error) {
+	query := url.Values{"role": {role}, "permission": {permission}}
+
+	client := upstream.For("users")
+	req, err := client.NewRequest(ctx, http.MethodGet, "/permissions/check?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.HTTPClient().Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("users: %s", details.ErrorMessage(resp))
+	}
+
+	var c check
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		return false, err
+	}
+
+	return c.Allowed, nil
+}
@@ -1,55 +1,382 @@
 package routes
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/4lerman/e_com/api/apikey"
+	"github.com/4lerman/e_com/api/auth"
+	"github.com/4lerman/e_com/api/cache"
+	"github.com/4lerman/e_com/api/catalog"
+	"github.com/4lerman/e_com/api/contract"
+	"github.com/4lerman/e_com/api/envelope"
+	"github.com/4lerman/e_com/api/graph"
 	"github.com/4lerman/e_com/api/handlers"
+	"github.com/4lerman/e_com/api/proxy"
+	"github.com/4lerman/e_com/api/ratelimit"
+	"github.com/4lerman/e_com/api/rbac"
+	"github.com/4lerman/e_com/api/sessions"
+	"github.com/4lerman/e_com/api/stream"
+	"github.com/4lerman/e_com/api/upstream"
+	configs "github.com/4lerman/e_com/common/config"
+	commonDb "github.com/4lerman/e_com/common/db"
+	"github.com/4lerman/e_com/common/health"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/metrics"
 	"github.com/gorilla/mux"
 
-	_ "github.com/4lerman/e_com/docs"
-    httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/4lerman/e_com/docs"
+	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func Routes(router *mux.Router) {
-	router.HandleFunc("/health-check", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	}).Methods(http.MethodGet)
+type Service struct {
+	proxy.Route
+	Policy      auth.Policy
+	Permissions auth.Permissions
+	Scopes      auth.Scopes
+	Public      []string
+	RateLimit   string
+	Timeout     int64
+	Cache       []cache.Rule
+	Invalidates []string
+	Deduplicate []*regexp.Regexp
+}
+
+// Services is the gateway route table of the API version under api: every
+// request under Prefix is proxied to the same path under Path on the
+// Upstream service, provided the caller's token satisfies Policy, or its
+// role holds the permission Permissions asks for, or its API key has the
+// scope Scopes asks for, and the caller is within RateLimit. Callers let
+// through by a permission act on every record, not just their own. Anyone
+// may POST to the Public paths under Prefix, within the auth rate limit.
+// Upstream calls time out after Timeout seconds, and POSTs to the
+// Deduplicate paths are retried when they carry an Idempotency-Key. When
+// the response cache is on, GET responses matching a Cache rule are
+// cached, and successful writes drop the cached responses of the
+// Invalidates services.
+func Services(api string) []Service {
+	return []Service{
+		{
+			Route:       proxy.Route{Name: "users", Prefix: api + "/users", Upstream: configs.Envs.Users_Url, Path: "/users"},
+			Policy:      auth.Any(auth.Admin, auth.OwnUser(api+"/users")),
+			Permissions: auth.Matching(regexp.MustCompile(`^`+api+`/users/[0-9]+/sessions(/|$)`), auth.CRUD("session"), auth.CRUD("user")),
+			Scopes:      auth.ReadWrite("users"),
+			Public:      []string{"/register", "/verify-email", "/verify-email/resend", "/password-reset", "/password-reset/confirm"},
+			RateLimit:   configs.Envs.Rate_Limit_Users,
+			Timeout:     configs.Envs.Users_Timeout,
+		},
+		{
+			// roles and the permission catalog live in the user service
+			Route:     proxy.Route{Name: "users", Prefix: api + "/roles", Upstream: configs.Envs.Users_Url, Path: "/roles"},
+			Policy:    auth.Admin,
+			RateLimit: configs.Envs.Rate_Limit_Users,
+			Timeout:   configs.Envs.Users_Timeout,
+		},
+		{
+			Route:     proxy.Route{Name: "users", Prefix: api + "/permissions", Upstream: configs.Envs.Users_Url, Path: "/permissions"},
+			Policy:    auth.Admin,
+			RateLimit: configs.Envs.Rate_Limit_Users,
+			Timeout:   configs.Envs.Users_Timeout,
+		},
+		{
+			Route:       proxy.Route{Name: "products", Prefix: api + "/products", Upstream: configs.Envs.Products_Url, Path: "/products"},
+			Policy:      auth.Any(auth.Admin, auth.ReadOnly),
+			Permissions: auth.CRUD("product"),
+			Scopes:      auth.ReadWrite("products"),
+			RateLimit:   configs.Envs.Rate_Limit_Products,
+			Timeout:     configs.Envs.Products_Timeout,
+			Cache: []cache.Rule{
+				{Path: regexp.MustCompile(`^` + api + `/products$`), TTL: seconds(configs.Envs.Product_List_Cache_Ttl)},
+				{Path: regexp.MustCompile(`^` + api + `/products/search$`), TTL: seconds(configs.Envs.Product_Search_Cache_Ttl)},
+				{Path: regexp.MustCompile(`^` + api + `/products/[0-9]+$`), TTL: seconds(configs.Envs.Product_Cache_Ttl)},
+			},
+			Invalidates: []string{"products"},
+		},
+		{
+			// clients only see their own orders, the order service filters them
+			Route:       proxy.Route{Name: "orders", Prefix: api + "/orders", Upstream: configs.Envs.Orders_Url, Path: "/orders"},
+			Policy:      auth.Any(auth.Admin, auth.Methods(http.MethodGet, http.MethodPost)),
+			Permissions: auth.CRUD("order"),
+			Scopes:      auth.ReadWrite("orders"),
+			RateLimit:   configs.Envs.Rate_Limit_Orders,
+			Timeout:     configs.Envs.Orders_Timeout,
+			// adding items to an order takes products out of stock
+			Invalidates: []string{"products"},
+			Deduplicate: []*regexp.Regexp{
+				regexp.MustCompile(`^` + api + `/orders$`),
+				regexp.MustCompile(`^` + api + `/orders/[0-9]+/order$`),
+			},
+		},
+		{
+			// refunds need payment:refund, the rest the CRUD permissions
+			Route:       proxy.Route{Name: "payments", Prefix: api + "/payments", Upstream: configs.Envs.Payments_Url, Path: "/payments"},
+			Policy:      auth.Any(auth.Admin, auth.Methods(http.MethodGet, http.MethodPost)),
+			Permissions: auth.Matching(regexp.MustCompile(`^`+api+`/payments/[0-9]+/refund$`), func(*http.Request) string { return "payment:refund" }, auth.CRUD("payment")),
+			Scopes:      auth.ReadWrite("payments"),
+			RateLimit:   configs.Envs.Rate_Limit_Payments,
+			Timeout:     configs.Envs.Payments_Timeout,
+			Deduplicate: []*regexp.Regexp{regexp.MustCompile(`^` + api + `/payments$`)},
+		},
+	}
+}
+
+// Versions are the API versions the gateway serves. They share upstreams,
+// limits and cached responses; v2 wraps every response in an envelope and
+// answers creates with the created resource.
+var Versions = []struct {
+	Prefix string
+	Wrap   func(http.Handler) http.Handler
+}{
+	{Prefix: "/api/v1"},
+	{Prefix: "/api/v2", Wrap: envelope.Middleware},
+}
+
+func Routes(router *mux.Router, db *sql.DB, broker *stream.Broker) error {
+	limiter, err := ratelimit.NewStore()
+	if err != nil {
+		return err
+	}
+
+	responses, err := cache.NewStore()
+	if err != nil {
+		return err
+	}
+
+	authLimit, err := ratelimit.ParseLimit(configs.Envs.Rate_Limit_Auth)
+	if err != nil {
+		return err
+	}
+
+	ordersLimit, err := ratelimit.ParseLimit(configs.Envs.Rate_Limit_Orders)
+	if err != nil {
+		return err
+	}
+
+	usersLimit, err := ratelimit.ParseLimit(configs.Envs.Rate_Limit_Users)
+	if err != nil {
+		return err
+	}
+
+	graphqlLimit, err := ratelimit.ParseLimit(configs.Envs.Rate_Limit_Graphql)
+	if err != nil {
+		return err
+	}
+
+	// order details and GraphQL read products over gRPC
+	if err := catalog.Connect(configs.Envs.Products_Grpc_Addr, seconds(configs.Envs.Products_Timeout)); err != nil {
+		return err
+	}
+
+	schema, err := graph.New(authorize(Services("/api/v1")), invalidate(Services("/api/v1"), responses))
+	if err != nil {
+		return err
+	}
+
+	// machine clients authenticate with API keys stored next to the services' data
+	keys := apikey.NewStore(db)
+	auth.UseKeys(keys)
+
+	// roles other than admin and client may do what the user service says
+	// their permissions allow
+	auth.UsePermissions(rbac.NewChecker(seconds(configs.Envs.Permission_Cache_Ttl)))
+
+	// access tokens stop working as soon as their session is revoked,
+	// which the user service notifies through Postgres
+	sessionChecker := sessions.NewChecker(seconds(configs.Envs.Session_Cache_Ttl))
+	if err := sessionChecker.Listen(commonDb.EnvConfig().ConnString()); err != nil {
+		return err
+	}
+	auth.UseSessions(sessionChecker)
+
+	// order and payment events reach the gateway as Postgres notifications
+	if err := broker.Listen(commonDb.EnvConfig().ConnString()); err != nil {
+		return err
+	}
+
+	router.Use(auth.StripIdentity)
+
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
 
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
-	router = router.PathPrefix("/api/v1").Subrouter()
-
-	usersRouter := router.PathPrefix("/users").Subrouter()
-	usersRouter.HandleFunc("", handlers.GetUsersHandler).Methods(http.MethodGet)
-	usersRouter.HandleFunc("", handlers.CreateUserHandler).Methods(http.MethodPost)
-	usersRouter.HandleFunc("/search", handlers.GetUserByQueryHandler).Methods(http.MethodGet)
-	usersRouter.HandleFunc("/{id}", handlers.GetUserByIDHandler).Methods(http.MethodGet)
-	usersRouter.HandleFunc("/{id}", handlers.UpdateUserHandler).Methods(http.MethodPut)
-	usersRouter.HandleFunc("/{id}", handlers.DeleteUserHandler).Methods(http.MethodDelete)
-
-
-	productsRouter := router.PathPrefix("/products").Subrouter()
-	productsRouter.HandleFunc("", handlers.GetProductsHandler).Methods(http.MethodGet)
-	productsRouter.HandleFunc("", handlers.CreateProductHandler).Methods(http.MethodPost)
-	productsRouter.HandleFunc("/search", handlers.GetProductByQueryHandler).Methods(http.MethodGet)
-	productsRouter.HandleFunc("/{id}", handlers.GetProductByIDHandler).Methods(http.MethodGet)
-	productsRouter.HandleFunc("/{id}", handlers.UpdateProductHandler).Methods(http.MethodPut)
-	productsRouter.HandleFunc("/{id}", handlers.DeleteProductHandler).Methods(http.MethodDelete)
-
-	ordersRouter := router.PathPrefix("/orders").Subrouter()
-	ordersRouter.HandleFunc("", handlers.GetOrdersHandler).Methods(http.MethodGet)
-	ordersRouter.HandleFunc("", handlers.CreateOrderHandler).Methods(http.MethodPost)
-	ordersRouter.HandleFunc("/search", handlers.GetOrdersByQueryHandler).Methods(http.MethodGet)
-	ordersRouter.HandleFunc("/{id}", handlers.GetOrderByIDHandler).Methods(http.MethodGet)
-	ordersRouter.HandleFunc("/{id}", handlers.UpdateOrderHandler).Methods(http.MethodPut)
-	ordersRouter.HandleFunc("/{id}", handlers.DeleteOrderHandler).Methods(http.MethodDelete)
-
-	paymentRouter := router.PathPrefix("/payments").Subrouter()
-	paymentRouter.HandleFunc("", handlers.GetPaymentsHandler).Methods(http.MethodGet)
-	paymentRouter.HandleFunc("", handlers.CreatePaymentHandler).Methods(http.MethodPost)
-	paymentRouter.HandleFunc("/search", handlers.GetPaymentsByQueryHandler).Methods(http.MethodGet)
-	paymentRouter.HandleFunc("/{id}", handlers.GetPaymentByIDHandler).Methods(http.MethodGet)
-	paymentRouter.HandleFunc("/{id}", handlers.UpdatePaymentHandler).Methods(http.MethodPut)
-	paymentRouter.HandleFunc("/{id}", handlers.DeletePaymentHandler).Methods(http.MethodDelete)
-}
\ No newline at end of file
+	graphqlHandler := ratelimit.Middleware(limiter, "graphql", graphqlLimit, handlers.GraphQLHandler(schema))
+	router.Handle("/graphql", auth.Authenticate(auth.Authenticated, nil, graphqlHandler)).Methods(http.MethodGet, http.MethodPost)
+
+	adminRouter := router.PathPrefix("/api/v1/admin").Subrouter()
+	adminRouter.Use(func(next http.Handler) http.Handler {
+		return auth.Authenticate(auth.Admin, nil, next)
+	})
+	adminRouter.HandleFunc("/upstreams", handlers.UpstreamsHandler).Methods(http.MethodGet)
+	handlers.NewAPIKeyHandler(keys).RegisterRoutes(adminRouter)
+
+	// requests, and in strict mode responses, are checked against the
+	// generated swagger spec
+	var spec *contract.Spec
+	if configs.Envs.Openapi_Validation != "off" {
+		prefixes := []string{}
+		for _, version := range Versions {
+			prefixes = append(prefixes, version.Prefix)
+		}
+
+		spec, err = contract.Load(docs.SwaggerInfo.ReadDoc(), prefixes)
+		if err != nil {
+			return err
+		}
+	}
+
+	checks := map[string]health.Check{
+		"db": health.DB(db),
+	}
+	clients := map[string]*upstream.Client{}
+	for _, version := range Versions {
+		wrap := version.Wrap
+		if wrap == nil {
+			wrap = func(h http.Handler) http.Handler { return h }
+		}
+
+		// the spec describes v1 responses; v2 ones are enveloped
+		checkResponses := configs.Envs.Openapi_Validation == "strict" && version.Wrap == nil
+		validate := func(h http.Handler) http.Handler {
+			if spec == nil {
+				return h
+			}
+			return contract.Middleware(spec, checkResponses, h)
+		}
+
+		authRouter := router.PathPrefix(version.Prefix + "/auth").Subrouter()
+		authRouter.Use(wrap, func(next http.Handler) http.Handler {
+			return ratelimit.Middleware(limiter, "auth", authLimit, next)
+		}, validate)
+		authRouter.HandleFunc("/token", handlers.LoginHandler).Methods(http.MethodPost)
+		authRouter.HandleFunc("/refresh", handlers.RefreshTokenHandler).Methods(http.MethodPost)
+
+		// registered before the orders proxy so it is not forwarded; the order
+		// service applies the ownership rules to every call made on its behalf
+		detailsHandler := ratelimit.Middleware(limiter, "orders", ordersLimit, validate(http.HandlerFunc(handlers.OrderDetailsHandler)))
+		router.Handle(version.Prefix+"/orders/{id:[0-9]+}/details", wrap(auth.Authenticate(auth.Authenticated, nil, detailsHandler))).Methods(http.MethodGet)
+
+		// exports collect from every service and may be zip archives, so they
+		// are neither proxied nor wrapped in the envelope; the user service
+		// only lets clients export their own data, and logs every export
+		exportHandler := ratelimit.Middleware(limiter, "users", usersLimit, http.HandlerFunc(handlers.ExportUserHandler))
+		router.Handle(version.Prefix+"/users/{id:[0-9]+}/export", auth.Authenticate(auth.Authenticated, nil, exportHandler)).Methods(http.MethodGet)
+
+		// events are streamed as they are, never wrapped in the envelope; they
+		// are about orders, so staff who may read every order get them all
+		eventsHandler := ratelimit.Middleware(limiter, "orders", ordersLimit, handlers.EventsHandler(broker))
+		eventsHandler = auth.Grant(func(*http.Request) string { return "order:read" }, eventsHandler)
+		router.Handle(version.Prefix+"/events", auth.QueryToken(auth.Authenticate(auth.Authenticated, nil, eventsHandler))).Methods(http.MethodGet)
+
+		for _, service := range Services(version.Prefix) {
+			client, ok := clients[service.Name]
+			if !ok {
+				client, err = upstream.New(service.Name, service.Upstream, time.Duration(service.Timeout)*time.Second)
+				if err != nil {
+					return err
+				}
+				clients[service.Name] = client
+				checks[service.Name] = client.Ready
+			}
+
+			p := proxy.New(service.Route, client)
+
+			limit, err := ratelimit.ParseLimit(service.RateLimit)
+			if err != nil {
+				return fmt.Errorf("%s: %w", service.Name, err)
+			}
+
+			var handler http.Handler = p
+			if len(service.Deduplicate) > 0 {
+				handler = upstream.Deduplicated(service.Deduplicate, handler)
+			}
+			if responses != nil && len(service.Cache) > 0 {
+				handler = cache.Cache(responses, service.Name, service.Cache, handler)
+			}
+			if responses != nil && len(service.Invalidates) > 0 {
+				handler = cache.Invalidate(responses, service.Invalidates, handler)
+			}
+
+			for _, path := range service.Public {
+				router.Handle(service.Prefix+path, wrap(ratelimit.Middleware(limiter, "auth", authLimit, validate(p)))).Methods(http.MethodPost)
+			}
+
+			handler = ratelimit.Middleware(limiter, service.Name, limit, validate(handler))
+			if service.Permissions != nil {
+				handler = auth.Grant(service.Permissions, handler)
+			}
+			handler = wrap(auth.Authenticate(service.policy(), service.Scopes, handler))
+			router.Path(service.Prefix).Handler(handler)
+			router.PathPrefix(service.Prefix + "/").Handler(handler)
+		}
+	}
+
+	// instances of every service come from the upstreams file, if there is one
+	err = upstream.Start(configs.Envs.Upstreams_File, seconds(configs.Envs.Upstream_Probe_Interval), int(configs.Envs.Upstream_Probe_Failures))
+	if err != nil {
+		return err
+	}
+
+	// the gateway is ready when every upstream is
+	health.Register(router, checks)
+	router.HandleFunc("/health-check", health.Ready(checks)).Methods(http.MethodGet)
+
+	return nil
+}
+
+// authorize applies the route policies to the upstream calls GraphQL
+// resolvers make, as if the caller made them through the gateway.
+func authorize(services []Service) graph.Authorizer {
+	return func(caller identity.Identity, service, method, path string) (identity.Identity, bool) {
+		for _, s := range services {
+			if s.Name == service {
+				r := &http.Request{Method: method, URL: &url.URL{Path: s.Prefix + strings.TrimPrefix(path, s.Path)}}
+				if !s.policy()(caller, r) {
+					return caller, false
+				}
+				if s.Permissions != nil {
+					caller = auth.Granted(caller, r, s.Permissions)
+				}
+				return caller, true
+			}
+		}
+
+		return caller, false
+	}
+}
+
+// invalidate drops the cached responses a GraphQL mutation made stale,
+// as Invalidate does for writes through the service's routes.
+func invalidate(services []Service, responses cache.Store) graph.Invalidator {
+	return func(ctx context.Context, service string) {
+		if responses == nil {
+			return
+		}
+
+		for _, s := range services {
+			if s.Name == service {
+				cache.Drop(ctx, responses, s.Invalidates)
+				return
+			}
+		}
+	}
+}
+
+// policy lets through whoever Policy does, and callers whose role holds
+// the permission the request needs.
+func (s Service) policy() auth.Policy {
+	if s.Permissions == nil {
+		return s.Policy
+	}
+
+	return auth.Any(s.Policy, auth.Permitted(s.Permissions))
+}
+
+func seconds(n int64) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,163 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/4lerman/e_com/api/details"
+	"github.com/4lerman/e_com/api/upstream"
+	"github.com/4lerman/e_com/common/events"
+	"github.com/lib/pq"
+)
+
+// Checker asks the user service's session check API whether sessions are
+// still active, and remembers the answers for a while. The user service
+// notifies revocations, which drop the remembered answer, so revoked
+// sessions stop working at once rather than after the TTL.
+type Checker struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	answers map[int]answer
+	// generation changes with every revocation, so answers fetched while
+	// one arrived are not remembered
+	generation uint64
+}
+
+type answer struct {
+	active  bool
+	expires time.Time
+}
+
+type check struct {
+	Active bool `json:"active"`
+}
+
+func NewChecker(ttl time.Duration) *Checker {
+	return &Checker{
+		ttl:     ttl,
+		answers: map[int]answer{},
+	}
+}
+
+// Listen starts receiving the notifications the user service sends for
+// revoked sessions on connStr's database, until the process exits.
+func (c *Checker) Listen(connStr string) error {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("session listener connection failed", "error", err)
+		}
+	})
+
+	if err := listener.Listen(events.SessionsChannel); err != nil {
+		listener.Close()
+		return err
+	}
+
+	go c.receive(listener)
+
+	return nil
+}
+
+func (c *Checker) receive(listener *pq.Listener) {
+	for {
+		select {
+		case n := <-listener.Notify:
+			if n == nil {
+				// the connection was reestablished; revocations sent in
+				// the meantime are lost, so forget everything
+				c.forget(nil)
+				continue
+			}
+
+			id, err := strconv.Atoi(n.Extra)
+			if err != nil {
+				slog.Warn("invalid session notification", "payload", n.Extra)
+				continue
+			}
+
+			c.forget(&id)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+// forget drops the answer for the session, or all answers if there is none.
+func (c *Checker) forget(id *int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if id == nil {
+		c.answers = map[int]answer{}
+		return
+	}
+
+	delete(c.answers, *id)
+}
+
+// Active tells whether the session is active. Tokens issued before
+// sessions carry no session ID, and are not.
+func (c *Checker) Active(ctx context.Context, id int) (bool, error) {
+	if id <= 0 {
+		return false, nil
+	}
+
+	c.mu.Lock()
+	a, ok := c.answers[id]
+	generation := c.generation
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(a.expires) {
+		return a.active, nil
+	}
+
+	active, err := ask(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	if c.ttl > 0 {
+		c.mu.Lock()
+		if c.generation == generation {
+			c.answers[id] = answer{active: active, expires: time.Now().Add(c.ttl)}
+		}
+		c.mu.Unlock()
+	}
+
+	return active, nil
+}
+
+// ask calls the user service as the gateway itself, not on behalf of the
+// caller.
+func ask(ctx context.Context, id int) (bool, error) {
+	client := upstream.For("users")
+	req, err := client.NewRequest(ctx, http.MethodGet, "/sessions/"+strconv.Itoa(id)+"/check", nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.HTTPClient().Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("users: %s", details.ErrorMessage(resp))
+	}
+
+	var c check
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		return false, err
+	}
+
+	return c.Active, nil
+}
@@ -0,0 +1,271 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/4lerman/e_com/common/events"
+	"github.com/lib/pq"
+)
+
+// ErrClosed ends a stream whose subscription was dropped, because the
+// client fell behind or the gateway is shutting down. The client resumes
+// from the last event it received when it reconnects.
+var ErrClosed = errors.New("event stream closed")
+
+const (
+	// events queued per subscriber before it is dropped as too slow
+	bufferSize = 64
+	// events read per query when a client resumes
+	pageSize = 500
+	// idle streams are pinged this often so proxies keep them open
+	pingInterval = 25 * time.Second
+)
+
+// Broker passes the events services publish on to the streams of
+// connected clients.
+type Broker struct {
+	store *events.Store
+
+	mu          sync.Mutex
+	subscribers map[*subscription]struct{}
+	last        int64
+	closed      bool
+}
+
+type subscription struct {
+	filter events.Filter
+	events chan events.Event
+}
+
+func NewBroker(store *events.Store) *Broker {
+	return &Broker{
+		store:       store,
+		subscribers: map[*subscription]struct{}{},
+	}
+}
+
+// Listen starts receiving the notifications services send for new events
+// on connStr's database, until the process exits.
+func (b *Broker) Listen(connStr string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	last, err := b.store.Last(ctx)
+	if err != nil {
+		return err
+	}
+	b.last = last
+
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Warn("event listener connection failed", "error", err)
+		}
+	})
+
+	if err := listener.Listen(events.Channel); err != nil {
+		listener.Close()
+		return err
+	}
+
+	go b.receive(listener)
+
+	return nil
+}
+
+func (b *Broker) receive(listener *pq.Listener) {
+	for {
+		select {
+		case n := <-listener.Notify:
+			if n == nil {
+				// the connection was reestablished; notifications sent in
+				// the meantime are lost, so catch up from the table
+				b.catchUp()
+				continue
+			}
+
+			id, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				slog.Warn("invalid event notification", "payload", n.Extra)
+				continue
+			}
+
+			b.fetch(id)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+func (b *Broker) fetch(id int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	event, err := b.store.GetEvent(ctx, id)
+	if err != nil {
+		slog.Warn("failed to read event", "event_id", id, "error", err)
+		return
+	}
+
+	b.dispatch(*event)
+}
+
+func (b *Broker) catchUp() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for {
+		b.mu.Lock()
+		last := b.last
+		b.mu.Unlock()
+
+		missed, err := b.store.Since(ctx, last, events.Filter{}, pageSize)
+		if err != nil {
+			slog.Warn("failed to read missed events", "error", err)
+			return
+		}
+
+		for _, event := range missed {
+			b.dispatch(event)
+		}
+
+		if len(missed) < pageSize {
+			return
+		}
+	}
+}
+
+// dispatch queues the event for every subscriber it matches. Subscribers
+// whose queue is full are dropped rather than holding up the others.
+func (b *Broker) dispatch(event events.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.last = max(b.last, event.ID)
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			slog.Warn("dropped slow event subscriber", "user_id", sub.filter.UserID)
+			b.unsubscribe(sub)
+		}
+	}
+}
+
+func (b *Broker) subscribe(filter events.Filter) *subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	sub := &subscription{filter: filter, events: make(chan events.Event, bufferSize)}
+	b.subscribers[sub] = struct{}{}
+	subscribers.Inc()
+
+	return sub
+}
+
+// unsubscribe must be called with b.mu held.
+func (b *Broker) unsubscribe(sub *subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+		subscribers.Dec()
+	}
+}
+
+// Close ends every stream and refuses new ones, so the gateway can shut
+// down without waiting for clients to leave.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.unsubscribe(sub)
+	}
+}
+
+// Stream sends the events matching filter to a client: first the stored
+// ones after the event with ID after, then new ones as they are published.
+// ping is called when there was nothing to send for a while, to keep the
+// connection open. It returns when ctx is done, send or ping fails, or the
+// subscription is dropped.
+func (b *Broker) Stream(ctx context.Context, filter events.Filter, after int64, send func(events.Event) error, ping func() error) error {
+	// subscribe before reading the backlog, so nothing published while it
+	// is read is missed
+	sub := b.subscribe(filter)
+	if sub == nil {
+		return ErrClosed
+	}
+	defer func() {
+		b.mu.Lock()
+		b.unsubscribe(sub)
+		b.mu.Unlock()
+	}()
+
+	deliver := func(event events.Event) error {
+		if err := send(event); err != nil {
+			return err
+		}
+
+		sent.WithLabelValues(event.Type).Inc()
+		return nil
+	}
+
+	replayed := map[int64]bool{}
+	for after > 0 {
+		backlog, err := b.store.Since(ctx, after, filter, pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range backlog {
+			if err := deliver(event); err != nil {
+				return err
+			}
+			replayed[event.ID] = true
+			after = event.ID
+		}
+
+		if len(backlog) < pageSize {
+			break
+		}
+	}
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.events:
+			if !ok {
+				return ErrClosed
+			}
+			if replayed[event.ID] {
+				continue
+			}
+
+			if err := deliver(event); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := ping(); err != nil {
+				return err
+			}
+		}
+	}
+}
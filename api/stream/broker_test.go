@@ -0,0 +1,196 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/4lerman/e_com/common/db/dbtest"
+	"github.com/4lerman/e_com/common/events"
+)
+
+func TestDispatch(t *testing.T) {
+	event := events.Event{ID: 1, Type: events.OrderStatusChanged, UserID: 7, OrderID: 3}
+
+	tests := []struct {
+		name    string
+		filter  events.Filter
+		queued  int
+		want    bool
+		dropped bool
+	}{
+		{"everything", events.Filter{}, 0, true, false},
+		{"same user", events.Filter{UserID: 7}, 0, true, false},
+		{"same order", events.Filter{UserID: 7, OrderID: 3}, 0, true, false},
+		{"other user", events.Filter{UserID: 8}, 0, false, false},
+		{"other order", events.Filter{UserID: 7, OrderID: 4}, 0, false, false},
+		{"queue almost full", events.Filter{}, bufferSize - 1, true, false},
+		{"queue full drops the subscriber", events.Filter{}, bufferSize, false, true},
+		{"full queue of a subscriber it does not match", events.Filter{UserID: 8}, bufferSize, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBroker(nil)
+			sub := b.subscribe(tt.filter)
+			for i := 0; i < tt.queued; i++ {
+				sub.events <- events.Event{}
+			}
+
+			b.dispatch(event)
+
+			got := false
+			for range tt.queued {
+				<-sub.events
+			}
+			select {
+			case e, ok := <-sub.events:
+				got = ok && e.ID == event.ID
+			default:
+			}
+			if got != tt.want {
+				t.Errorf("delivered = %v, want %v", got, tt.want)
+			}
+
+			b.mu.Lock()
+			_, subscribed := b.subscribers[sub]
+			b.mu.Unlock()
+			if subscribed == tt.dropped {
+				t.Errorf("subscribed = %v, want %v", subscribed, !tt.dropped)
+			}
+		})
+	}
+}
+
+func TestStreamDropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(nil)
+
+	release := make(chan struct{})
+	received := 0
+	done := make(chan error)
+	go func() {
+		done <- b.Stream(context.Background(), events.Filter{}, 0, func(events.Event) error {
+			<-release
+			received++
+			return nil
+		}, func() error { return nil })
+	}()
+
+	for {
+		b.mu.Lock()
+		n := len(b.subscribers)
+		b.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// one event is taken and blocks in send, bufferSize fill the queue
+	// and the next one overflows it
+	for id := int64(1); id <= bufferSize+2; id++ {
+		b.dispatch(events.Event{ID: id})
+		if id == 1 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	close(release)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClosed) {
+			t.Fatalf("Stream() error = %v, want %v", err, ErrClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stream() did not return after its subscriber was dropped")
+	}
+
+	if received != bufferSize+1 {
+		t.Errorf("received %d events before the drop, want %d", received, bufferSize+1)
+	}
+}
+
+func TestStreamReplay(t *testing.T) {
+	db := dbtest.Open(t)
+	b := NewBroker(events.NewStore(db))
+
+	userId := int(time.Now().UnixNano() % 1_000_000_000)
+	var ids []int64
+	for orderId := 1; orderId <= 3; orderId++ {
+		if err := events.Publish(context.Background(), db, events.OrderStatusChanged, userId, orderId, map[string]string{"status": "paid"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, err := b.store.Since(context.Background(), 0, events.Filter{UserID: userId}, pageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range rows {
+		ids = append(ids, event.ID)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("published %d events, want 3", len(ids))
+	}
+
+	tests := []struct {
+		name   string
+		filter events.Filter
+		after  int64
+		want   []int64
+	}{
+		{"after the first", events.Filter{UserID: userId}, ids[0], ids[1:]},
+		{"after the last", events.Filter{UserID: userId}, ids[2], nil},
+		{"one order", events.Filter{UserID: userId, OrderID: 2}, ids[0], ids[1:2]},
+		{"no Last-Event-ID", events.Filter{UserID: userId}, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			got := make(chan int64, 10)
+			done := make(chan error)
+			go func() {
+				done <- b.Stream(ctx, tt.filter, tt.after, func(event events.Event) error {
+					got <- event.ID
+					return nil
+				}, func() error { return nil })
+			}()
+
+			for _, want := range tt.want {
+				select {
+				case id := <-got:
+					if id != want {
+						t.Fatalf("replayed event %d, want %d", id, want)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("event %d was not replayed", want)
+				}
+			}
+
+			// events published while the backlog was read come in live too
+			// and are not sent twice
+			for _, id := range tt.want {
+				b.dispatch(events.Event{ID: id, UserID: userId, OrderID: 2})
+			}
+			live := events.Event{ID: ids[2] + 1_000_000, UserID: userId, OrderID: 2}
+			b.dispatch(live)
+
+			select {
+			case id := <-got:
+				if id != live.ID {
+					t.Fatalf("sent event %d, want the live event %d", id, live.ID)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("live event was not sent")
+			}
+
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("Stream() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package stream
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	subscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "event_streams",
+		Help: "Event streams open at the gateway, over SSE and WebSocket.",
+	})
+
+	sent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_sent_total",
+		Help: "Events sent to streaming clients, by event type.",
+	}, []string{"type"})
+)
@@ -0,0 +1,53 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/4lerman/e_com/common/events"
+)
+
+// retryDelay is how long EventSource clients wait before reconnecting, in
+// milliseconds.
+const retryDelay = 3000
+
+// ServeSSE streams events as Server-Sent Events. Each one carries its ID,
+// which browsers send back in Last-Event-ID when they reconnect.
+func (b *Broker) ServeSSE(w http.ResponseWriter, r *http.Request, filter events.Filter, after int64) error {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// keep nginx and similar proxies from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", retryDelay)
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+
+	send := func(event events.Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	ping := func() error {
+		if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	return b.Stream(r.Context(), filter, after, send, ping)
+}
@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/events"
+	"github.com/gorilla/websocket"
+)
+
+const writeTimeout = 10 * time.Second
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: checkOrigin,
+}
+
+// ServeWebSocket upgrades the connection and sends every event as a JSON
+// text message. Clients resume by passing the id of the last one they got
+// in last_event_id when they reconnect. Anything they send is ignored.
+func (b *Broker) ServeWebSocket(w http.ResponseWriter, r *http.Request, filter events.Filter, after int64) error {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has answered the request
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// reading is how closes and pongs are noticed
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event events.Event) error {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return conn.WriteJSON(event)
+	}
+
+	ping := func() error {
+		return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+	}
+
+	err = b.Stream(ctx, filter, after, send, ping)
+
+	code := websocket.CloseNormalClosure
+	if errors.Is(err, ErrClosed) {
+		code = websocket.CloseTryAgainLater
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), time.Now().Add(writeTimeout))
+
+	return err
+}
+
+// checkOrigin accepts connections from the gateway's own origin, the
+// frontend at BASE_URL and clients that are not browsers.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	if u.Host == r.Host {
+		return true
+	}
+
+	base, err := url.Parse(configs.Envs.Base_Url)
+	return err == nil && u.Scheme == base.Scheme && u.Host == base.Host
+}
@@ -0,0 +1,61 @@
+package upstream
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	const (
+		allow   = "allow"
+		deny    = "deny"
+		succeed = "succeed"
+		fail    = "fail"
+		release = "release"
+		cool    = "cool down"
+	)
+
+	tests := []struct {
+		name  string
+		steps []string
+		want  State
+	}{
+		{"stays closed below the threshold", []string{allow, fail, allow, fail, allow}, Closed},
+		{"success resets the failure count", []string{fail, fail, succeed, fail, fail, allow}, Closed},
+		{"opens at the threshold", []string{fail, fail, fail, deny}, Open},
+		{"half-open after the cooldown", []string{fail, fail, fail, cool, allow}, HalfOpen},
+		{"only one probe at a time", []string{fail, fail, fail, cool, allow, deny}, HalfOpen},
+		{"successful probe closes it", []string{fail, fail, fail, cool, allow, succeed, allow}, Closed},
+		{"failed probe re-opens it", []string{fail, fail, fail, cool, allow, fail, deny}, Open},
+		{"released probe lets another through", []string{fail, fail, fail, cool, allow, release, allow}, HalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreaker(3, time.Minute)
+
+			for i, step := range tt.steps {
+				switch step {
+				case allow, deny:
+					ok, wait := b.Allow()
+					if ok != (step == allow) {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, ok, step == allow)
+					}
+					if !ok && wait <= 0 {
+						t.Fatalf("step %d: denied without a wait", i)
+					}
+				case succeed, fail:
+					b.Record(step == succeed)
+				case release:
+					b.Release()
+				case cool:
+					b.openedAt = b.openedAt.Add(-b.cooldown)
+				}
+			}
+
+			if status := b.Status("orders"); status.State != tt.want {
+				t.Errorf("state = %s, want %s", status.State, tt.want)
+			}
+		})
+	}
+}
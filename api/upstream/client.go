@@ -0,0 +1,380 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/idempotency"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/tracing"
+)
+
+// maxReplayBody is the largest request body buffered so the request can be
+// retried; larger requests are sent once.
+const maxReplayBody = 1 << 20
+
+// transport is shared by every upstream so connections are pooled. Each
+// attempt gets a client span and carries the trace context upstream.
+var transport = tracing.Transport(&http.Transport{
+	Proxy:               http.ProxyFromEnvironment,
+	MaxIdleConns:        200,
+	MaxIdleConnsPerHost: 50,
+	IdleConnTimeout:     90 * time.Second,
+	TLSHandshakeTimeout: 10 * time.Second,
+})
+
+var (
+	mu      sync.Mutex
+	clients = map[string]*Client{}
+)
+
+// Client calls one upstream service. It is an http.RoundTripper, so it can
+// back both the reverse proxy and plain http.Client calls. Requests are
+// addressed to Target and every attempt is sent to an instance of the
+// service picked by its pool.
+type Client struct {
+	name     string
+	instance *url.URL
+	pool     *Pool
+	timeout  time.Duration
+	retries  int
+	breaker  *Breaker
+}
+
+// New creates the client of the named upstream and registers it, so the
+// same client and breaker are used for every call to that service. The
+// service runs at baseURL until the upstreams file lists its instances.
+func New(name, baseURL string, timeout time.Duration) (*Client, error) {
+	instance, err := parseInstance(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	c := &Client{
+		name:     name,
+		instance: instance,
+		pool:     &Pool{},
+		timeout:  timeout,
+		retries:  int(configs.Envs.Upstream_Retries),
+		breaker:  NewBreaker(int(configs.Envs.Breaker_Failures), time.Duration(configs.Envs.Breaker_Cooldown)*time.Second),
+	}
+	c.pool.set(RoundRobin, []*url.URL{instance})
+	clients[name] = c
+
+	return c, nil
+}
+
+// For returns the registered client of the named upstream. It panics if
+// the gateway route table has no such service.
+func For(name string) *Client {
+	mu.Lock()
+	defer mu.Unlock()
+
+	c, ok := clients[name]
+	if !ok {
+		panic("upstream: unknown service " + name)
+	}
+
+	return c
+}
+
+// Breakers reports the breaker state of every registered upstream.
+func Breakers() []BreakerStatus {
+	mu.Lock()
+	defer mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(clients))
+	for name, c := range clients {
+		status := c.breaker.Status(name)
+		status.Instances = c.pool.Status()
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Service < statuses[j].Service
+	})
+
+	return statuses
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Target is the address requests to the service are built against; the
+// client replaces its host with the instance each attempt goes to.
+func (c *Client) Target() *url.URL {
+	return &url.URL{Scheme: "http", Host: c.name}
+}
+
+// HTTPClient wraps the client for direct calls from gateway handlers.
+func (c *Client) HTTPClient() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// NewRequest builds a request to path on the upstream.
+func (c *Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.Target().String()+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
+
+// Ready reports the upstream ready when any of its instances answers its
+// /readyz. Probes bypass retries and the breaker so they report the
+// service as it is.
+func (c *Client) Ready(ctx context.Context) error {
+	var errs []error
+	for _, in := range c.pool.snapshot() {
+		err := in.probe(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("%s: %w", c.name, ErrNoInstances)
+	}
+
+	return errors.Join(errs...)
+}
+
+// RoundTrip sends the request, retrying idempotent requests with jittered
+// backoff on connection errors and 502/503/504 responses. POSTs with an
+// Idempotency-Key are idempotent when Deduplicated marked them.
+func (c *Client) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if idempotent(req) && replayable(req) {
+		attempts += c.retries
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(req.Context(), backoff(attempt)); err != nil {
+				return nil, err
+			}
+
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+
+			upstreamRetries.WithLabelValues(c.name).Inc()
+		}
+
+		start := time.Now()
+		resp, err = c.send(req)
+		observe(c.name, req.Method, start, resp, err)
+
+		if !retryable(resp, err) || attempt == attempts-1 {
+			break
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+
+	return resp, err
+}
+
+// send makes a single attempt through the breaker to one instance. The
+// timeout covers the time until response headers arrive, so streamed
+// bodies are not cut off.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	in, err := c.pool.pick()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	if ok, wait := c.breaker.Allow(); !ok {
+		return nil, &BreakerError{Service: c.name, RetryAfter: wait}
+	}
+
+	// the instance counts as busy until the response body is closed
+	in.active.Add(1)
+	ctx, cancelCtx := context.WithCancel(req.Context())
+	cancel := sync.OnceFunc(func() {
+		cancelCtx()
+		in.active.Add(-1)
+	})
+	req = withRequestID(in.route(req.WithContext(ctx)))
+	// route cloned the request, so its headers are the attempt's own
+	req.Header.Set(identity.InternalHeader, configs.Envs.Internal_Secret)
+
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(c.timeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+
+	resp, err := transport.RoundTrip(req)
+	timer.Stop()
+
+	switch {
+	case timedOut.Load():
+		cancel()
+		if resp != nil {
+			resp.Body.Close()
+		}
+		c.breaker.Record(false)
+		return nil, fmt.Errorf("%s: %w after %s", c.name, ErrTimeout, c.timeout)
+	case err != nil:
+		// checked before cancel, which cancels the attempt's context too
+		canceled := req.Context().Err() != nil
+		cancel()
+		if canceled {
+			c.breaker.Release()
+		} else {
+			c.breaker.Record(false)
+		}
+		return nil, err
+	}
+
+	c.breaker.Record(!unavailable(resp.StatusCode))
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// withRequestID passes the request ID of the request being served on to
+// the upstream, unless the request already carries one.
+func withRequestID(req *http.Request) *http.Request {
+	id := logger.RequestID(req.Context())
+	if id == "" || req.Header.Get(logger.RequestIDHeader) != "" {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(logger.RequestIDHeader, id)
+
+	return req
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+type deduplicatedKey struct{}
+
+// Deduplicated marks the POSTs to paths whose upstream handler deduplicates
+// requests by Idempotency-Key, so those carrying a key are retried like
+// idempotent methods. Other services would run a retried POST twice.
+func Deduplicated(paths []*regexp.Regexp, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			for _, path := range paths {
+				if path.MatchString(r.URL.Path) {
+					r = r.WithContext(context.WithValue(r.Context(), deduplicatedKey{}, true))
+					break
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func idempotent(req *http.Request) bool {
+	if req.Header.Get(idempotency.Header) != "" && req.Context().Value(deduplicatedKey{}) != nil {
+		return true
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// replayable makes sure the body can be sent again, buffering small bodies.
+func replayable(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return true
+	}
+
+	if req.ContentLength < 0 || req.ContentLength > maxReplayBody {
+		return false
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return false
+	}
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+
+	return true
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen) && !errors.Is(err, context.Canceled)
+	}
+
+	return unavailable(resp.StatusCode)
+}
+
+func unavailable(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// backoff is exponential with full jitter: 0..100ms, 0..200ms, 0..400ms...
+func backoff(attempt int) time.Duration {
+	ceiling := 100 * time.Millisecond << (attempt - 1)
+	return rand.N(ceiling)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/4lerman/e_com/common/idempotency"
+)
+
+func newTestClient(t *testing.T, retries int, breaker *Breaker, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	c := &Client{
+		name:     "orders",
+		instance: u,
+		pool:     &Pool{},
+		timeout:  time.Second,
+		retries:  retries,
+		breaker:  breaker,
+	}
+	c.pool.set(RoundRobin, []*url.URL{u})
+
+	return c
+}
+
+func TestRoundTripRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		key          bool
+		deduplicated bool
+		failures     int
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{"GET retried until it succeeds", http.MethodGet, false, false, 2, http.StatusOK, 3},
+		{"GET gives up after the retries", http.MethodGet, false, false, 5, http.StatusServiceUnavailable, 3},
+		{"POST sent once", http.MethodPost, false, false, 2, http.StatusServiceUnavailable, 1},
+		{"POST with a key to another service sent once", http.MethodPost, true, false, 2, http.StatusServiceUnavailable, 1},
+		{"deduplicated POST with a key retried", http.MethodPost, true, true, 2, http.StatusOK, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			c := newTestClient(t, 2, NewBreaker(100, time.Minute), func(w http.ResponseWriter, r *http.Request) {
+				if int(attempts.Add(1)) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			})
+
+			var paths []*regexp.Regexp
+			if tt.deduplicated {
+				paths = []*regexp.Regexp{regexp.MustCompile(`^/orders$`)}
+			}
+
+			var resp *http.Response
+			var err error
+			r := httptest.NewRequest(tt.method, "/orders", nil)
+			Deduplicated(paths, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req, _ := c.NewRequest(r.Context(), tt.method, "/orders", strings.NewReader(`{}`))
+				if tt.key {
+					req.Header.Set(idempotency.Header, "abc")
+				}
+				resp, err = c.RoundTrip(req)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+
+			if err != nil {
+				t.Fatalf("RoundTrip() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus || attempts.Load() != tt.wantAttempts {
+				t.Errorf("got %d after %d attempts, want %d after %d", resp.StatusCode, attempts.Load(), tt.wantStatus, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRoundTripOpensBreaker(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, 0, NewBreaker(2, time.Minute), func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	for i := 0; i < 2; i++ {
+		req, _ := c.NewRequest(context.Background(), http.MethodGet, "/orders", nil)
+		resp, err := c.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("call %d: RoundTrip() error = %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, "/orders", nil)
+	_, err := c.RoundTrip(req)
+
+	var breakerErr *BreakerError
+	if !errors.As(err, &breakerErr) || breakerErr.RetryAfter <= 0 {
+		t.Fatalf("RoundTrip() error = %v, want an open breaker", err)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("upstream called %d times, want 2", attempts.Load())
+	}
+
+	w := httptest.NewRecorder()
+	WriteError(w, "orders", err)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("WriteError() = %d with Retry-After %q, want 503 with 60", w.Code, w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,49 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/4lerman/e_com/common/utils"
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	ErrTimeout     = errors.New("upstream timed out")
+)
+
+type BreakerError struct {
+	Service    string
+	RetryAfter time.Duration
+}
+
+func (e *BreakerError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Service, ErrCircuitOpen)
+}
+
+func (e *BreakerError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// WriteError answers a failed upstream call: 503 when the service's
+// circuit is open or none of its instances is healthy, 504 on timeout and
+// 502 otherwise.
+func WriteError(w http.ResponseWriter, service string, err error) {
+	var breakerErr *BreakerError
+
+	switch {
+	case errors.As(err, &breakerErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(breakerErr.RetryAfter.Seconds()))))
+		utils.WriteError(w, http.StatusServiceUnavailable, fmt.Errorf("%s service is temporarily unavailable, try again later", service))
+	case errors.Is(err, ErrNoInstances):
+		utils.WriteError(w, http.StatusServiceUnavailable, fmt.Errorf("%s service has no healthy instances, try again later", service))
+	case errors.Is(err, ErrTimeout):
+		utils.WriteError(w, http.StatusGatewayTimeout, fmt.Errorf("%s service did not respond in time", service))
+	default:
+		utils.WriteError(w, http.StatusBadGateway, fmt.Errorf("%s service is unavailable: %v", service, err))
+	}
+}
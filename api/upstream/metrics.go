@@ -0,0 +1,106 @@
+package upstream
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_requests_total",
+		Help: "Attempts to call upstream services, by service, method and result: the status code, timeout, circuit_open, no_instances or error.",
+	}, []string{"service", "method", "result"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upstream_request_duration_seconds",
+		Help:    "Time until upstream response headers arrived, by service.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service"})
+
+	upstreamRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_retries_total",
+		Help: "Upstream calls retried, by service.",
+	}, []string{"service"})
+)
+
+func init() {
+	prometheus.MustRegister(breakerCollector{})
+}
+
+var (
+	breakerStateDesc = prometheus.NewDesc(
+		"upstream_circuit_state",
+		"Circuit breaker state of each upstream service; 1 for the current state.",
+		[]string{"service", "state"}, nil,
+	)
+
+	instanceUpDesc = prometheus.NewDesc(
+		"upstream_instance_up",
+		"Whether each upstream instance is in rotation, by its last probes.",
+		[]string{"service", "instance"}, nil,
+	)
+
+	instanceActiveDesc = prometheus.NewDesc(
+		"upstream_instance_active_requests",
+		"Requests in flight to each upstream instance.",
+		[]string{"service", "instance"}, nil,
+	)
+)
+
+// breakerCollector reports the breakers and instances at scrape time.
+type breakerCollector struct{}
+
+func (breakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- breakerStateDesc
+	ch <- instanceUpDesc
+	ch <- instanceActiveDesc
+}
+
+func (breakerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, status := range Breakers() {
+		for _, state := range []State{Closed, Open, HalfOpen} {
+			value := 0.0
+			if status.State == state {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(breakerStateDesc, prometheus.GaugeValue, value, status.Service, string(state))
+		}
+
+		for _, in := range status.Instances {
+			up := 0.0
+			if in.Healthy {
+				up = 1
+			}
+			ch <- prometheus.MustNewConstMetric(instanceUpDesc, prometheus.GaugeValue, up, status.Service, in.URL)
+			ch <- prometheus.MustNewConstMetric(instanceActiveDesc, prometheus.GaugeValue, float64(in.Active), status.Service, in.URL)
+		}
+	}
+}
+
+// observe records one attempt: its status when it got a response, else the
+// kind of error.
+func observe(service, method string, start time.Time, resp *http.Response, err error) {
+	var result string
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		result = "circuit_open"
+	case errors.Is(err, ErrNoInstances):
+		result = "no_instances"
+	case errors.Is(err, ErrTimeout):
+		result = "timeout"
+	case err != nil:
+		result = "error"
+	default:
+		result = strconv.Itoa(resp.StatusCode)
+	}
+
+	upstreamRequests.WithLabelValues(service, method, result).Inc()
+	if err == nil || errors.Is(err, ErrTimeout) {
+		upstreamDuration.WithLabelValues(service).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,170 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Balancers decide which healthy instance of a service gets each attempt.
+const (
+	RoundRobin       = "round_robin"
+	LeastConnections = "least_connections"
+)
+
+var ErrNoInstances = errors.New("no healthy instances")
+
+// Instance is one replica of an upstream service.
+type Instance struct {
+	URL *url.URL
+
+	healthy atomic.Bool
+	// failures counts consecutive failed probes
+	failures atomic.Int64
+	active   atomic.Int64
+}
+
+type InstanceStatus struct {
+	URL     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+	Active  int64  `json:"active"`
+}
+
+// Pool holds the instances of a service. Instances start healthy and are
+// taken out by the prober while their /readyz fails.
+type Pool struct {
+	mu        sync.RWMutex
+	balancer  string
+	instances []*Instance
+	next      atomic.Uint64
+}
+
+func parseInstance(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSuffix(raw, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance url %q: %w", raw, err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid instance url %q", raw)
+	}
+
+	return u, nil
+}
+
+// set replaces the instances, keeping the health and load of the ones that
+// stay so a reload does not put failing instances back into rotation.
+func (p *Pool) set(balancer string, urls []*url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := make(map[string]*Instance, len(p.instances))
+	for _, in := range p.instances {
+		current[in.URL.String()] = in
+	}
+
+	instances := make([]*Instance, 0, len(urls))
+	for _, u := range urls {
+		in, ok := current[u.String()]
+		if !ok {
+			in = &Instance{URL: u}
+			in.healthy.Store(true)
+		}
+		instances = append(instances, in)
+	}
+
+	p.balancer = balancer
+	p.instances = instances
+}
+
+func (p *Pool) snapshot() []*Instance {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.instances
+}
+
+// pick chooses the instance for the next attempt. Least connections breaks
+// ties round-robin so idle instances share the load.
+func (p *Pool) pick() (*Instance, error) {
+	p.mu.RLock()
+	balancer, instances := p.balancer, p.instances
+	p.mu.RUnlock()
+
+	healthy := make([]*Instance, 0, len(instances))
+	for _, in := range instances {
+		if in.healthy.Load() {
+			healthy = append(healthy, in)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil, ErrNoInstances
+	}
+
+	start := int((p.next.Add(1) - 1) % uint64(len(healthy)))
+	best := healthy[start]
+	if balancer == LeastConnections {
+		for i := 1; i < len(healthy); i++ {
+			if in := healthy[(start+i)%len(healthy)]; in.active.Load() < best.active.Load() {
+				best = in
+			}
+		}
+	}
+
+	return best, nil
+}
+
+func (p *Pool) Status() []InstanceStatus {
+	instances := p.snapshot()
+
+	statuses := make([]InstanceStatus, 0, len(instances))
+	for _, in := range instances {
+		statuses = append(statuses, InstanceStatus{
+			URL:     in.URL.String(),
+			Healthy: in.healthy.Load(),
+			Active:  in.active.Load(),
+		})
+	}
+
+	return statuses
+}
+
+// route points req at the instance.
+func (in *Instance) route(req *http.Request) *http.Request {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = in.URL.Scheme
+	out.URL.Host = in.URL.Host
+	out.URL.Path = in.URL.Path + req.URL.Path
+	out.URL.RawPath = ""
+	out.Host = ""
+
+	return out
+}
+
+// probe asks the instance's /readyz whether it can serve traffic.
+func (in *Instance) probe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, in.URL.String()+"/readyz", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := transport.RoundTrip(withRequestID(req))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s", in.URL, resp.Status)
+	}
+
+	return nil
+}
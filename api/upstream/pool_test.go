@@ -0,0 +1,205 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func testURLs(raw ...string) []*url.URL {
+	urls := make([]*url.URL, 0, len(raw))
+	for _, r := range raw {
+		u, _ := parseInstance(r)
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+func TestPoolPick(t *testing.T) {
+	tests := []struct {
+		name     string
+		balancer string
+		// unhealthy and active are indexed like the instances a, b and c
+		unhealthy []int
+		active    []int64
+		want      []string
+		wantErr   error
+	}{
+		{
+			name:     "round robin takes turns",
+			balancer: RoundRobin,
+			want:     []string{"a", "b", "c", "a", "b", "c"},
+		},
+		{
+			name:      "round robin skips ejected instances",
+			balancer:  RoundRobin,
+			unhealthy: []int{1},
+			want:      []string{"a", "c", "a", "c"},
+		},
+		{
+			name:     "round robin ignores load",
+			balancer: RoundRobin,
+			active:   []int64{5, 0, 0},
+			want:     []string{"a", "b", "c"},
+		},
+		{
+			name:     "least connections picks the idlest",
+			balancer: LeastConnections,
+			active:   []int64{3, 1, 2},
+			want:     []string{"b", "b", "b"},
+		},
+		{
+			name:     "least connections shares ties",
+			balancer: LeastConnections,
+			active:   []int64{1, 1, 4},
+			want:     []string{"a", "b", "a", "a"},
+		},
+		{
+			name:      "least connections skips ejected instances",
+			balancer:  LeastConnections,
+			unhealthy: []int{1},
+			active:    []int64{3, 0, 2},
+			want:      []string{"c", "c"},
+		},
+		{
+			name:      "no healthy instance",
+			balancer:  RoundRobin,
+			unhealthy: []int{0, 1, 2},
+			wantErr:   ErrNoInstances,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pool{}
+			p.set(tt.balancer, testURLs("http://a", "http://b", "http://c"))
+
+			instances := p.snapshot()
+			for _, i := range tt.unhealthy {
+				instances[i].healthy.Store(false)
+			}
+			for i, n := range tt.active {
+				instances[i].active.Store(n)
+			}
+
+			if tt.wantErr != nil {
+				if _, err := p.pick(); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("pick() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			for i, want := range tt.want {
+				in, err := p.pick()
+				if err != nil {
+					t.Fatalf("pick %d: error = %v", i+1, err)
+				}
+				if in.URL.Host != want {
+					t.Errorf("pick %d = %s, want %s", i+1, in.URL.Host, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPoolSetKeepsHealth(t *testing.T) {
+	p := &Pool{}
+	p.set(RoundRobin, testURLs("http://a", "http://b"))
+	p.snapshot()[0].healthy.Store(false)
+
+	p.set(LeastConnections, testURLs("http://a", "http://c"))
+
+	for _, status := range p.Status() {
+		if want := status.URL != "http://a"; status.Healthy != want {
+			t.Errorf("%s healthy = %v, want %v", status.URL, status.Healthy, want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			t.Errorf("probed %s, want /readyz", r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	in := &Instance{URL: testURLs(server.URL)[0]}
+	in.healthy.Store(true)
+
+	tests := []struct {
+		name    string
+		status  int
+		healthy bool
+	}{
+		{"first failure keeps it in", http.StatusServiceUnavailable, true},
+		{"second failure keeps it in", http.StatusServiceUnavailable, true},
+		{"third failure takes it out", http.StatusServiceUnavailable, false},
+		{"still failing", http.StatusInternalServerError, false},
+		{"first success brings it back", http.StatusOK, true},
+		{"failures count from zero again", http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status = tt.status
+			check(context.Background(), "orders", in, 3)
+
+			if in.healthy.Load() != tt.healthy {
+				t.Errorf("healthy = %v, want %v", in.healthy.Load(), tt.healthy)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	c, err := New("apply-test", "http://apply-test:8080", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(clients, "apply-test")
+		mu.Unlock()
+	})
+
+	tests := []struct {
+		name    string
+		service ServiceInstances
+		wantErr bool
+		want    []string
+	}{
+		{"unknown balancer", ServiceInstances{Balancer: "random", Instances: []string{"http://a"}}, true, []string{"http://apply-test:8080"}},
+		{"no instances", ServiceInstances{}, true, []string{"http://apply-test:8080"}},
+		{"invalid instance", ServiceInstances{Instances: []string{"a:8080"}}, true, []string{"http://apply-test:8080"}},
+		{"instances replace the configured URL", ServiceInstances{Instances: []string{"http://a/", "http://b"}}, false, []string{"http://a", "http://b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := apply(&Registry{Services: map[string]ServiceInstances{"apply-test": tt.service}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			statuses := c.pool.Status()
+			if len(statuses) != len(tt.want) {
+				t.Fatalf("instances = %+v, want %v", statuses, tt.want)
+			}
+			for i, status := range statuses {
+				if status.URL != tt.want[i] {
+					t.Errorf("instance %d = %s, want %s", i, status.URL, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if err := apply(&Registry{Services: map[string]ServiceInstances{"missing": {Instances: []string{"http://a"}}}}); err == nil {
+		t.Errorf("apply() accepted an unknown service")
+	}
+}
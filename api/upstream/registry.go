@@ -0,0 +1,207 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Registry is the upstreams file: the instances of each service and how
+// attempts are balanced across them. Services it leaves out keep their
+// configured URL. JSON files work too, JSON being a subset of YAML.
+//
+//	services:
+//	  orders:
+//	    balancer: least_connections
+//	    instances:
+//	      - http://order-service-1:8083
+//	      - http://order-service-2:8083
+type Registry struct {
+	Services map[string]ServiceInstances `yaml:"services"`
+}
+
+type ServiceInstances struct {
+	// Balancer is round_robin, the default, or least_connections.
+	Balancer  string   `yaml:"balancer"`
+	Instances []string `yaml:"instances"`
+}
+
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upstreams file: %w", err)
+	}
+
+	var registry Registry
+	if err := yaml.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("invalid upstreams file %s: %w", path, err)
+	}
+
+	return &registry, nil
+}
+
+// apply checks the whole registry before changing any pool, so a bad edit
+// leaves the running instances alone.
+func apply(registry *Registry) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	type update struct {
+		balancer string
+		urls     []*url.URL
+	}
+	updates := make(map[string]update, len(clients))
+
+	for name, service := range registry.Services {
+		if _, ok := clients[name]; !ok {
+			return fmt.Errorf("unknown service %q", name)
+		}
+
+		balancer := service.Balancer
+		if balancer == "" {
+			balancer = RoundRobin
+		}
+		if balancer != RoundRobin && balancer != LeastConnections {
+			return fmt.Errorf("%s: unknown balancer %q", name, service.Balancer)
+		}
+
+		if len(service.Instances) == 0 {
+			return fmt.Errorf("%s: no instances", name)
+		}
+
+		urls := make([]*url.URL, 0, len(service.Instances))
+		for _, raw := range service.Instances {
+			u, err := parseInstance(raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			urls = append(urls, u)
+		}
+
+		updates[name] = update{balancer: balancer, urls: urls}
+	}
+
+	for name, c := range clients {
+		u, ok := updates[name]
+		if !ok {
+			u = update{balancer: RoundRobin, urls: []*url.URL{c.instance}}
+		}
+		c.pool.set(u.balancer, u.urls)
+	}
+
+	return nil
+}
+
+// Start applies the upstreams file at path, if any, then keeps the pools
+// current: every interval it reloads the file when it has changed and
+// probes every instance, taking an instance out after failures failed
+// probes in a row and back in on its first success. Only an unusable file
+// at startup is an error; later bad edits are logged and skipped.
+func Start(path string, interval time.Duration, failures int) error {
+	var modTime time.Time
+	if path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read upstreams file: %w", err)
+		}
+
+		registry, err := LoadRegistry(path)
+		if err != nil {
+			return err
+		}
+
+		if err := apply(registry); err != nil {
+			return fmt.Errorf("invalid upstreams file %s: %w", path, err)
+		}
+		modTime = info.ModTime()
+	}
+
+	go func() {
+		for range time.Tick(interval) {
+			if path != "" {
+				modTime = reload(path, modTime)
+			}
+			probe(time.Duration(configs.Envs.Health_Timeout)*time.Second, failures)
+		}
+	}()
+
+	return nil
+}
+
+// reload applies the file if it changed since modTime and returns the
+// modification time it has seen.
+func reload(path string, modTime time.Time) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		slog.Error("failed to read upstreams file", "path", path, "error", err)
+		return modTime
+	}
+
+	if info.ModTime().Equal(modTime) {
+		return modTime
+	}
+
+	registry, err := LoadRegistry(path)
+	if err == nil {
+		err = apply(registry)
+	}
+	if err != nil {
+		slog.Error("upstreams file not reloaded", "path", path, "error", err)
+		return info.ModTime()
+	}
+
+	slog.Info("upstreams file reloaded", "path", path)
+
+	return info.ModTime()
+}
+
+// probe checks every instance once, all of them at the same time, each
+// within timeout.
+func probe(timeout time.Duration, failures int) {
+	mu.Lock()
+	pools := make(map[string]*Pool, len(clients))
+	for name, c := range clients {
+		pools[name] = c.pool
+	}
+	mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	count := 0
+	for name, pool := range pools {
+		for _, in := range pool.snapshot() {
+			count++
+			go func() {
+				defer func() { done <- struct{}{} }()
+				check(ctx, name, in, failures)
+			}()
+		}
+	}
+
+	for range count {
+		<-done
+	}
+}
+
+func check(ctx context.Context, service string, in *Instance, failures int) {
+	err := in.probe(ctx)
+	if err == nil {
+		in.failures.Store(0)
+		if !in.healthy.Swap(true) {
+			slog.Info("upstream instance recovered", "service", service, "instance", in.URL.String())
+		}
+		return
+	}
+
+	if in.failures.Add(1) >= int64(failures) && in.healthy.Swap(false) {
+		slog.Warn("upstream instance taken out of rotation", "service", service, "instance", in.URL.String(), "error", err)
+	}
+}
@@ -14,6 +14,11 @@ type Config struct {
 	Orders_Port   string
 	Payments_Port string
 
+	Users_Grpc_Port    string
+	Products_Grpc_Port string
+	Orders_Grpc_Port   string
+	Payments_Grpc_Port string
+
 	DBUser     string
 	DBPassword string
 	DBAddress  string
@@ -26,8 +31,75 @@ type Config struct {
 	Orders_Url  string
 	Payments_Url string
 
+	Users_Grpc_Addr    string
+	Products_Grpc_Addr string
+	Orders_Grpc_Addr   string
+
 	Token_Url        string
 	Make_Payment_Url string
+
+	JWT_Secret        string
+	Internal_Secret   string
+	Access_Token_TTL  int64
+	Refresh_Token_TTL int64
+
+	Permission_Cache_Ttl int64
+	Session_Cache_Ttl    int64
+
+	Admin_Email    string
+	Admin_Password string
+
+	Password_Reset_Ttl     int64
+	Email_Verification_Ttl int64
+
+	Mail_Backend  string
+	Mail_File     string
+	Mail_From     string
+	Smtp_Addr     string
+	Smtp_Username string
+	Smtp_Password string
+	Frontend_Url  string
+
+	Redis_Url string
+
+	Rate_Limit_Backend  string
+	Rate_Limit_Auth     string
+	Rate_Limit_Users    string
+	Rate_Limit_Products string
+	Rate_Limit_Orders   string
+	Rate_Limit_Payments string
+	Rate_Limit_Graphql  string
+
+	Users_Timeout    int64
+	Products_Timeout int64
+	Orders_Timeout   int64
+	Payments_Timeout int64
+	Upstream_Retries int64
+	Breaker_Failures int64
+	Breaker_Cooldown int64
+
+	Upstreams_File          string
+	Upstream_Probe_Interval int64
+	Upstream_Probe_Failures int64
+
+	Health_Timeout int64
+
+	Idempotency_Key_Ttl  int64
+	Idempotency_Lock_Ttl int64
+
+	Event_Retention int64
+
+	Openapi_Validation string
+
+	Cache_Backend            string
+	Product_List_Cache_Ttl   int64
+	Product_Search_Cache_Ttl int64
+	Product_Cache_Ttl        int64
+
+	Log_Level string
+
+	Trace_Exporter string
+	Otlp_Endpoint  string
 }
 
 var Envs = initConfig()
@@ -42,6 +114,11 @@ func initConfig() Config {
 		Orders_Port:   getEnv("ORDERS_PORT", "8083"),
 		Payments_Port: getEnv("PAYMENTS_PORT", "8084"),
 
+		Users_Grpc_Port:    getEnv("USERS_GRPC_PORT", "9081"),
+		Products_Grpc_Port: getEnv("PRODUCTS_GRPC_PORT", "9082"),
+		Orders_Grpc_Port:   getEnv("ORDERS_GRPC_PORT", "9083"),
+		Payments_Grpc_Port: getEnv("PAYMENTS_GRPC_PORT", "9084"),
+
 		DBUser:     getEnv("DB_USER", "root"),
 		DBPassword: getEnv("DB_PASSWORD", "mypassword"),
 		DBAddress:  getEnv("DB_HOST", "127.0.0.1"),
@@ -54,8 +131,100 @@ func initConfig() Config {
 		Orders_Url:  getEnv("ORDERS_URL", "http://localhost:8083/"),
 		Payments_Url: getEnv("PAYMENTS_URL", "http://localhost:8084/"),
 
+		// host:port of the user, product and order services' gRPC APIs
+		Users_Grpc_Addr:    getEnv("USERS_GRPC_ADDR", "localhost:9081"),
+		Products_Grpc_Addr: getEnv("PRODUCTS_GRPC_ADDR", "localhost:9082"),
+		Orders_Grpc_Addr:   getEnv("ORDERS_GRPC_ADDR", "localhost:9083"),
+
 		Token_Url:        getEnv("TOKEN_URL", "https://testoauth.homebank.kz/epay2/oauth2/token"),
 		Make_Payment_Url: getEnv("MAKE_PAYMENT_URL", "https://testepay.homebank.kz/api/payment/cryptopay"),
+
+		// signs access tokens and email verification links; there is no
+		// default, the gateway and the user service refuse to start without it
+		JWT_Secret:        getEnv("JWT_SECRET", ""),
+		Access_Token_TTL:  getEnvAsInt("ACCESS_TOKEN_TTL", 15*60),
+		Refresh_Token_TTL: getEnvAsInt("REFRESH_TOKEN_TTL", 7*24*60*60),
+		// the gateway sends it with every call to a service, and the
+		// services take no calls without it; there is no default either
+		Internal_Secret: getEnv("INTERNAL_SECRET", ""),
+
+		// the gateway remembers what roles may do for this many seconds
+		Permission_Cache_Ttl: getEnvAsInt("PERMISSION_CACHE_TTL", 30),
+		// the gateway remembers active sessions for this many seconds;
+		// revocations reach it at once regardless
+		Session_Cache_Ttl: getEnvAsInt("SESSION_CACHE_TTL", 60),
+
+		Admin_Email:    getEnv("ADMIN_EMAIL", ""),
+		Admin_Password: getEnv("ADMIN_PASSWORD", ""),
+
+		// password reset tokens can be used for this many seconds
+		Password_Reset_Ttl: getEnvAsInt("PASSWORD_RESET_TTL", 60*60),
+		// email verification links work for this many seconds
+		Email_Verification_Ttl: getEnvAsInt("EMAIL_VERIFICATION_TTL", 24*60*60),
+
+		// file writes mail to MAIL_FILE, or stdout when it is empty; smtp
+		// sends it through SMTP_ADDR. Required, so a deployment never drops
+		// its mail into the logs unnoticed
+		Mail_Backend:  getEnv("MAIL_BACKEND", ""),
+		Mail_File:     getEnv("MAIL_FILE", ""),
+		Mail_From:     getEnv("MAIL_FROM", "no-reply@localhost"),
+		Smtp_Addr:     getEnv("SMTP_ADDR", "localhost:587"),
+		Smtp_Username: getEnv("SMTP_USERNAME", ""),
+		Smtp_Password: getEnv("SMTP_PASSWORD", ""),
+		// links in emails open pages under this URL; without it emails
+		// carry the bare tokens
+		Frontend_Url: getEnv("FRONTEND_URL", ""),
+
+		Redis_Url: getEnv("REDIS_URL", "redis://localhost:6379/0"),
+
+		// limits are rate:burst, in requests per second per client
+		Rate_Limit_Backend:  getEnv("RATE_LIMIT_BACKEND", "memory"),
+		Rate_Limit_Auth:     getEnv("RATE_LIMIT_AUTH", "1:5"),
+		Rate_Limit_Users:    getEnv("RATE_LIMIT_USERS", "10:20"),
+		Rate_Limit_Products: getEnv("RATE_LIMIT_PRODUCTS", "20:40"),
+		Rate_Limit_Orders:   getEnv("RATE_LIMIT_ORDERS", "10:20"),
+		Rate_Limit_Payments: getEnv("RATE_LIMIT_PAYMENTS", "5:10"),
+		Rate_Limit_Graphql:  getEnv("RATE_LIMIT_GRAPHQL", "5:10"),
+
+		// timeouts and cooldown are in seconds
+		Users_Timeout:    getEnvAsInt("USERS_TIMEOUT", 5),
+		Products_Timeout: getEnvAsInt("PRODUCTS_TIMEOUT", 5),
+		Orders_Timeout:   getEnvAsInt("ORDERS_TIMEOUT", 10),
+		Payments_Timeout: getEnvAsInt("PAYMENTS_TIMEOUT", 30),
+		Upstream_Retries: getEnvAsInt("UPSTREAM_RETRIES", 2),
+		Breaker_Failures: getEnvAsInt("BREAKER_FAILURES", 5),
+		Breaker_Cooldown: getEnvAsInt("BREAKER_COOLDOWN", 30),
+
+		Upstreams_File:          getEnv("UPSTREAMS_FILE", ""),
+		Upstream_Probe_Interval: getEnvAsInt("UPSTREAM_PROBE_INTERVAL", 5),
+		Upstream_Probe_Failures: getEnvAsInt("UPSTREAM_PROBE_FAILURES", 2),
+
+		// readiness checks time out after this many seconds each
+		Health_Timeout: getEnvAsInt("HEALTH_TIMEOUT", 2),
+
+		// responses to requests with an Idempotency-Key are replayed for this many seconds
+		Idempotency_Key_Ttl: getEnvAsInt("IDEMPOTENCY_KEY_TTL", 24*60*60),
+		// a request holds its key this many seconds at most; keep it above
+		// the gateway's ORDERS_TIMEOUT and PAYMENTS_TIMEOUT
+		Idempotency_Lock_Ttl: getEnvAsInt("IDEMPOTENCY_LOCK_TTL", 60),
+
+		// order and payment events can be replayed for this many seconds
+		Event_Retention: getEnvAsInt("EVENT_RETENTION", 7*24*60*60),
+
+		// off, requests or strict, which also checks and logs responses
+		Openapi_Validation: getEnv("OPENAPI_VALIDATION", "requests"),
+
+		// none, memory or redis; TTLs are in seconds, 0 disables the route
+		Cache_Backend:            getEnv("CACHE_BACKEND", "none"),
+		Product_List_Cache_Ttl:   getEnvAsInt("PRODUCT_LIST_CACHE_TTL", 30),
+		Product_Search_Cache_Ttl: getEnvAsInt("PRODUCT_SEARCH_CACHE_TTL", 10),
+		Product_Cache_Ttl:        getEnvAsInt("PRODUCT_CACHE_TTL", 60),
+
+		Log_Level: getEnv("LOG_LEVEL", "info"),
+
+		// none, stdout or otlp
+		Trace_Exporter: getEnv("TRACE_EXPORTER", "none"),
+		Otlp_Endpoint:  getEnv("OTLP_ENDPOINT", "localhost:4318"),
 	}
 }
 
@@ -0,0 +1,32 @@
+package configs
+
+import "fmt"
+
+// minSecretLength is the length in bytes of the shortest secret accepted.
+const minSecretLength = 32
+
+// knownSecrets are placeholder values from the examples and from earlier
+// defaults. Anyone can read them, so they protect nothing.
+var knownSecrets = map[string]bool{
+	"{}":                      true,
+	"not-so-secret-change-me": true,
+	"secret":                  true,
+	"changeme":                true,
+	"change-me":               true,
+}
+
+// CheckSecret returns an error unless the secret read from the env variable
+// name is set to a value of its own, at least minSecretLength bytes long.
+// Services refuse to start with secrets anyone could guess.
+func CheckSecret(name, value string) error {
+	switch {
+	case value == "":
+		return fmt.Errorf("%s is not set", name)
+	case knownSecrets[value]:
+		return fmt.Errorf("%s is set to a published example value", name)
+	case len(value) < minSecretLength:
+		return fmt.Errorf("%s must be at least %d bytes long", name, minSecretLength)
+	}
+
+	return nil
+}
@@ -3,7 +3,6 @@ package db
 import (
 	"database/sql"
 	"fmt"
-	"log"
 
 	configs "github.com/4lerman/e_com/common/config"
 	_ "github.com/lib/pq"
@@ -17,29 +16,37 @@ type DbConfig struct {
 	Dbname   string
 }
 
-func NewPSQLStorage(cfg *DbConfig) (*sql.DB, error) {
+// EnvConfig is the database the environment points at.
+func EnvConfig() *DbConfig {
+	return &DbConfig{
+		Host:     configs.Envs.DBAddress,
+		User:     configs.Envs.DBUser,
+		Port:     configs.Envs.DBPort,
+		Dbname:   configs.Envs.DBName,
+		Password: configs.Envs.DBPassword,
+	}
+}
 
-	connStr := fmt.Sprintf("host=%s port=%d user=%s "+
+// ConnString is the lib/pq connection string of the database.
+func (cfg *DbConfig) ConnString() string {
+	return fmt.Sprintf("host=%s port=%d user=%s "+
 		"password=%s dbname=%s sslmode=disable",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Dbname)
+}
+
+func NewPSQLStorage(cfg *DbConfig) (*sql.DB, error) {
 
-	db, err := sql.Open("postgres", connStr)
+	db, err := sql.Open("postgres", cfg.ConnString())
 
 	if err != nil {
-		log.Fatal("Error occured when connecting to db:", err)
+		return nil, fmt.Errorf("error occured when connecting to db: %w", err)
 	}
 
 	return db, nil
 }
 
 func InitStorage() (*sql.DB, error) {
-	db, err := NewPSQLStorage(&DbConfig{
-		Host:     configs.Envs.DBAddress,
-		User:     configs.Envs.DBUser,
-		Port:     configs.Envs.DBPort,
-		Dbname:   configs.Envs.DBName,
-		Password: configs.Envs.DBPassword,
-	})
+	db, err := NewPSQLStorage(EnvConfig())
 
 	if err != nil {
 		return nil, fmt.Errorf("DB init error: %v", err)
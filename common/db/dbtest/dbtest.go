@@ -0,0 +1,36 @@
+// Package dbtest connects tests to a migrated Postgres database.
+package dbtest
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// Env names the variable holding the test database's connection URL.
+const Env = "TEST_DATABASE_URL"
+
+// Open connects to the database at TEST_DATABASE_URL, or skips the test if
+// the variable is unset. The connection is closed when the test ends.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv(Env)
+	if url == "" {
+		t.Skip(Env + " is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	return db
+}
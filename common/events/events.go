@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Channel is the Postgres notification channel new event IDs are sent on.
+const Channel = "events"
+
+// SessionsChannel is the Postgres notification channel the IDs of revoked
+// sessions are sent on, so the gateway stops accepting their tokens.
+const SessionsChannel = "sessions_revoked"
+
+const (
+	OrderStatusChanged = "order.status_changed"
+	PaymentCreated     = "payment.created"
+	PaymentUpdated     = "payment.updated"
+)
+
+// Event is a change to an order or one of its payments. IDs grow with
+// every event in the order events commit, so a client can resume a stream
+// after the last one it saw.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	UserID    int             `json:"user_id"`
+	OrderID   int             `json:"order_id"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Filter selects the events of one user and one order. Zero fields match
+// everything.
+type Filter struct {
+	UserID  int
+	OrderID int
+}
+
+func (f Filter) Match(event Event) bool {
+	return (f.UserID == 0 || event.UserID == f.UserID) && (f.OrderID == 0 || event.OrderID == f.OrderID)
+}
+
+// publishLock is the advisory lock Publish holds until its transaction
+// ends. Event IDs come from a sequence, so without it a transaction could
+// commit an event after one with a higher ID was delivered, and clients
+// resuming after that one would never see it.
+const publishLock int64 = 0x6576656e7473 // "events"
+
+// Execer is a *sql.DB or *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Publish records an event and notifies the listeners on Channel once it is
+// committed. Pass the transaction that makes the change, so the event is
+// recorded if and only if the change is. Events commit in the order of
+// their IDs: publishing transactions take turns from Publish until they
+// end, so call it right before committing.
+func Publish(ctx context.Context, exec Execer, typ string, userId, orderId int, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := exec.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", publishLock); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", typ, err)
+	}
+
+	_, err = exec.ExecContext(ctx, "WITH event AS ("+
+		"INSERT INTO events (type, userId, orderId, data) VALUES ($1, $2, $3, $4) RETURNING id"+
+		") SELECT pg_notify($5, id::text) FROM event", typ, userId, orderId, string(payload), Channel)
+	if err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", typ, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/4lerman/e_com/common/tracing"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// Last returns the ID of the newest event, or 0 if there is none.
+func (s *Store) Last(ctx context.Context) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM events").Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last event: %w", err)
+	}
+
+	return id, nil
+}
+
+func (s *Store) GetEvent(ctx context.Context, id int64) (*Event, error) {
+	ctx, span := tracing.Start(ctx, "EventStore.GetEvent")
+	defer span.End()
+
+	var event Event
+	err := s.db.QueryRowContext(ctx, "SELECT id, type, userId, orderId, data, createdAt FROM events WHERE id = $1", id).
+		Scan(&event.ID, &event.Type, &event.UserID, &event.OrderID, &event.Data, &event.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("event not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	return &event, nil
+}
+
+// Since returns up to limit events after the one with ID after that
+// match filter, oldest first.
+func (s *Store) Since(ctx context.Context, after int64, filter Filter, limit int) ([]Event, error) {
+	ctx, span := tracing.Start(ctx, "EventStore.Since")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, type, userId, orderId, data, createdAt FROM events "+
+		"WHERE id > $1 AND ($2 = 0 OR userId = $2) AND ($3 = 0 OR orderId = $3) ORDER BY id LIMIT $4",
+		after, filter.UserID, filter.OrderID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(&event.ID, &event.Type, &event.UserID, &event.OrderID, &event.Data, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// PurgeEvery deletes events older than retention every interval, until the
+// process exits. Clients cannot resume from purged events.
+func (s *Store) PurgeEvery(retention, interval time.Duration) {
+	for range time.Tick(interval) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		res, err := s.db.ExecContext(ctx, "DELETE FROM events WHERE createdAt <= CURRENT_TIMESTAMP - make_interval(secs => $1)", int64(retention.Seconds()))
+		cancel()
+
+		if err != nil {
+			slog.Warn("failed to purge old events", "error", err)
+			continue
+		}
+
+		if n, _ := res.RowsAffected(); n > 0 {
+			slog.Info("purged old events", "count", n)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/gorilla/mux"
+)
+
+const (
+	Up   = "up"
+	Down = "down"
+)
+
+// Check reports whether a dependency of the service is usable.
+type Check func(ctx context.Context) error
+
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Register serves /healthz, which only tells the process is alive, and
+// /readyz, which runs the checks and answers 503 unless all of them pass.
+func Register(router *mux.Router, checks map[string]Check) {
+	router.HandleFunc("/healthz", Live).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", Ready(checks)).Methods(http.MethodGet)
+}
+
+func Live(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, Report{Status: Up, Checks: map[string]Result{}})
+}
+
+func Ready(checks map[string]Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := Run(r.Context(), checks)
+
+		status := http.StatusOK
+		if report.Status != Up {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		utils.WriteJSON(w, status, report)
+	}
+}
+
+// Run runs the checks concurrently, each bounded by HEALTH_TIMEOUT.
+func Run(ctx context.Context, checks map[string]Check) Report {
+	timeout := time.Duration(configs.Envs.Health_Timeout) * time.Second
+
+	report := Report{Status: Up, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx)
+			result := Result{Status: Up, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				result.Status = Down
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = Down
+			}
+		}(name, check)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+// DB checks the database answers a ping.
+func DB(db *sql.DB) Check {
+	return db.PingContext
+}
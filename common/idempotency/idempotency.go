@@ -0,0 +1,208 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// Header carries the client's key for a request it may retry.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks responses replayed from the store.
+const ReplayedHeader = "Idempotent-Replayed"
+
+const (
+	maxKeyLength = 255
+	maxBody      = 1 << 20
+)
+
+// replayHeaders are the response headers stored along with the body; the
+// others belong to the request that produced the response.
+var replayHeaders = []string{"Content-Type", "Content-Language", "Location", "Preference-Applied"}
+
+// Middleware makes a create endpoint safe to retry. The first request with
+// an Idempotency-Key header is handled and its response stored; repeats
+// with the same key get that response back without running the handler
+// again. A repeat with a different method, path or body is rejected with
+// 422, and one that arrives while the first is still running with 409.
+// Server errors are not stored, so the request can be retried with the
+// same key, unless the handler called Keep. Keys are scoped to the caller,
+// requests without one are handled as usual.
+func Middleware(store *Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("%s must be at most %d characters", Header, maxKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+			return
+		}
+		if len(body) > maxBody {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body is too large"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := scopeOf(r)
+		fingerprint := fingerprintOf(r, body)
+
+		existing, err := store.reserve(r.Context(), scope, key, fingerprint)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		switch {
+		case existing == nil:
+			handle(store, scope, key, w, r, next)
+		case existing.Fingerprint != fingerprint:
+			utils.WriteError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s was already used for a different request", Header))
+		case existing.Response == nil:
+			w.Header().Set("Retry-After", "1")
+			utils.WriteError(w, http.StatusConflict, fmt.Errorf("a request with this %s is still being processed", Header))
+		default:
+			replay(w, existing.Response)
+		}
+	})
+}
+
+// handle runs the request that holds the key and stores its response.
+func handle(store *Store, scope, key string, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+
+	// the response must be stored even if the client is gone by then
+	ctx := context.WithoutCancel(r.Context())
+
+	defer func() {
+		if !rec.wroteHeader || (rec.status >= http.StatusInternalServerError && !rec.keep) {
+			// nothing was sent, or the request failed on our side and may
+			// not fail again, so it can be tried again
+			if err := store.release(ctx, scope, key); err != nil {
+				logger.FromContext(ctx).Warn("failed to release idempotency key", "error", err)
+			}
+			return
+		}
+
+		resp := Response{Status: rec.status, Header: http.Header{}, Body: rec.body.Bytes()}
+		for _, name := range replayHeaders {
+			if values := w.Header().Values(name); len(values) > 0 {
+				resp.Header[name] = values
+			}
+		}
+
+		storeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		if err := store.complete(storeCtx, scope, key, resp); err != nil {
+			logger.FromContext(ctx).Error("failed to store idempotent response", "error", err)
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+}
+
+// Keep stores the response to the request w belongs to even if it is a
+// server error. Handlers call it before a side effect that must not happen
+// twice, such as charging a card, so a failure after it is replayed to
+// retries instead of running the handler again.
+func Keep(w http.ResponseWriter) {
+	for {
+		switch rw := w.(type) {
+		case *recorder:
+			rw.keep = true
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, resp *Response) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// scopeOf keeps the keys of different callers apart. API key clients share
+// the service role and have no user, so they are told apart by key.
+func scopeOf(r *http.Request) string {
+	id, ok := identity.FromRequest(r)
+	if !ok {
+		return "internal"
+	}
+
+	if id.IsService() {
+		return fmt.Sprintf("key:%d", id.KeyID)
+	}
+
+	return fmt.Sprintf("%s:%d", id.Role, id.UserID)
+}
+
+// fingerprintOf identifies the request a key was first used for.
+func fingerprintOf(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes the response through and keeps a copy to store.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	// keep is set by Keep
+	keep bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// RecordError passes handler errors on to the access log.
+func (rec *recorder) RecordError(err error) {
+	if r, ok := rec.ResponseWriter.(interface{ RecordError(error) }); ok {
+		r.RecordError(err)
+	}
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
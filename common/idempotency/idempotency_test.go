@@ -0,0 +1,108 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/4lerman/e_com/common/db/dbtest"
+)
+
+func TestMiddlewareReplay(t *testing.T) {
+	store := NewStore(dbtest.Open(t), time.Hour, time.Minute)
+
+	calls := 0
+	handler := Middleware(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", fmt.Sprintf("/api/v1/orders/%d", calls))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d}`, calls)
+	}))
+
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	send := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/orders", strings.NewReader(body))
+		r.Header.Set(Header, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := send(`{"product_id":1}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first request status = %d, want %d", first.Code, http.StatusCreated)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		want     int
+		replayed bool
+	}{
+		{"same request", `{"product_id":1}`, http.StatusCreated, true},
+		{"different body", `{"product_id":2}`, http.StatusUnprocessableEntity, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(tt.body)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if replayed := w.Header().Get(ReplayedHeader) == "true"; replayed != tt.replayed {
+				t.Errorf("%s = %q, want replayed %v", ReplayedHeader, w.Header().Get(ReplayedHeader), tt.replayed)
+			}
+			if tt.replayed {
+				if w.Body.String() != first.Body.String() {
+					t.Errorf("body = %s, want %s", w.Body, first.Body)
+				}
+				if w.Header().Get("Location") != first.Header().Get("Location") {
+					t.Errorf("Location = %q, want %q", w.Header().Get("Location"), first.Header().Get("Location"))
+				}
+			}
+		})
+	}
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestReserveTakesOverAbandonedKeys(t *testing.T) {
+	db := dbtest.Open(t)
+
+	tests := []struct {
+		name  string
+		lease time.Duration
+		free  bool
+	}{
+		{"held within its lease", time.Minute, false},
+		{"abandoned past its lease", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(db, time.Hour, tt.lease)
+			ctx := context.Background()
+			key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+
+			if existing, err := store.reserve(ctx, "test", key, "fingerprint"); err != nil || existing != nil {
+				t.Fatalf("reserve() = %v, %v, want the key", existing, err)
+			}
+
+			// the first request never completes, as if its process died
+			existing, err := store.reserve(ctx, "test", key, "fingerprint")
+			if err != nil {
+				t.Fatalf("reserve() error = %v", err)
+			}
+			if free := existing == nil; free != tt.free {
+				t.Errorf("retry got the key = %v, want %v", free, tt.free)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/4lerman/e_com/common/tracing"
+)
+
+// Response is a stored response, replayed for repeats of its request.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// record is what is stored about a key. Response is nil while the first
+// request with the key is still being handled.
+type record struct {
+	Fingerprint string
+	Response    *Response
+}
+
+type Store struct {
+	db    *sql.DB
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// NewStore keeps keys and their responses for ttl. A request holds its key
+// for lease at most: if it has not completed by then, its process is taken
+// to be gone and the key is free to be retried.
+func NewStore(db *sql.DB, ttl time.Duration, lease time.Duration) *Store {
+	return &Store{
+		db:    db,
+		ttl:   ttl,
+		lease: lease,
+	}
+}
+
+// reserve claims the key for a request with the given fingerprint. It
+// returns nil if the claim succeeded, or what is stored for the key if
+// another request holds it.
+func (s *Store) reserve(ctx context.Context, scope, key, fingerprint string) (*record, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyStore.Reserve")
+	defer span.End()
+
+	// an expired key is free to be used again, and so is one whose request
+	// never completed because its process died holding it
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND idempotencyKey = $2 AND "+
+		"(expiresAt <= CURRENT_TIMESTAMP OR (status IS NULL AND lockedUntil <= CURRENT_TIMESTAMP))", scope, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire idempotency key: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO idempotency_keys (scope, idempotencyKey, fingerprint, expiresAt, lockedUntil) "+
+		"VALUES ($1, $2, $3, CURRENT_TIMESTAMP + make_interval(secs => $4), CURRENT_TIMESTAMP + make_interval(secs => $5)) ON CONFLICT DO NOTHING",
+		scope, key, fingerprint, int64(s.ttl.Seconds()), int64(s.lease.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, nil
+	}
+
+	var (
+		rec     record
+		status  sql.NullInt64
+		headers []byte
+		body    []byte
+	)
+	err = s.db.QueryRowContext(ctx, "SELECT fingerprint, status, headers, body FROM idempotency_keys WHERE scope = $1 AND idempotencyKey = $2", scope, key).
+		Scan(&rec.Fingerprint, &status, &headers, &body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// released by a request that failed in the meantime
+		return s.reserve(ctx, scope, key, fingerprint)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if status.Valid {
+		rec.Response = &Response{Status: int(status.Int64), Body: body}
+		if err := json.Unmarshal(headers, &rec.Response.Header); err != nil {
+			return nil, fmt.Errorf("failed to read stored response: %w", err)
+		}
+	}
+
+	return &rec, nil
+}
+
+// complete stores the response to the request holding the key.
+func (s *Store) complete(ctx context.Context, scope, key string, resp Response) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyStore.Complete")
+	defer span.End()
+
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "UPDATE idempotency_keys SET status = $1, headers = $2, body = $3 WHERE scope = $4 AND idempotencyKey = $5",
+		resp.Status, string(headers), resp.Body, scope, key)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// release frees the key of a request that produced no response, or a
+// server error, so it can be retried.
+func (s *Store) release(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND idempotencyKey = $2 AND status IS NULL", scope, key)
+	return err
+}
+
+// PurgeEvery deletes expired keys every interval, until the process exits.
+func (s *Store) PurgeEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		res, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expiresAt <= CURRENT_TIMESTAMP")
+		cancel()
+
+		if err != nil {
+			slog.Warn("failed to purge expired idempotency keys", "error", err)
+			continue
+		}
+
+		if n, _ := res.RowsAffected(); n > 0 {
+			slog.Info("purged expired idempotency keys", "count", n)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package identity
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/4lerman/e_com/common/utils"
+)
+
+// Headers the gateway sets after verifying a token. They are stripped from
+// every inbound request at the gateway, and the services only take requests
+// carrying the internal secret, so services can trust them.
+const (
+	UserIDHeader     = "X-User-ID"
+	UserRoleHeader   = "X-User-Role"
+	PermissionHeader = "X-User-Permission"
+	KeyIDHeader      = "X-API-Key-ID"
+	SessionIDHeader  = "X-Session-ID"
+)
+
+// InternalHeader carries INTERNAL_SECRET, which the gateway sends to prove
+// a request comes from it.
+const InternalHeader = "X-Internal-Token"
+
+// openPaths are served without the internal secret, to health probes and
+// metrics scrapers.
+var openPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+const (
+	Admin  = "admin"
+	Client = "client"
+	// Service is the role of machine clients calling with an API key. The
+	// gateway limits them to the scopes of their key, so the services do
+	// not restrict them to records of their own.
+	Service = "service"
+)
+
+type Identity struct {
+	UserID int
+	Role   string
+	// Permission is the permission of the caller's role the gateway let
+	// the request through by, if any. Callers with one act on every record,
+	// not just their own.
+	Permission string
+	// KeyID is the API key a service caller authenticated with.
+	KeyID int
+	// SessionID is the session the caller's access token was issued in.
+	SessionID int
+}
+
+// FromRequest reads the identity the gateway attached to the request. ok is
+// false for calls the gateway makes on its own behalf, such as checking
+// credentials, and for the public routes.
+func FromRequest(r *http.Request) (id Identity, ok bool) {
+	userId, err := strconv.Atoi(r.Header.Get(UserIDHeader))
+	if err != nil {
+		return Identity{}, false
+	}
+
+	keyId, _ := strconv.Atoi(r.Header.Get(KeyIDHeader))
+	sessionId, _ := strconv.Atoi(r.Header.Get(SessionIDHeader))
+
+	return Identity{UserID: userId, Role: r.Header.Get(UserRoleHeader), Permission: r.Header.Get(PermissionHeader), KeyID: keyId, SessionID: sessionId}, true
+}
+
+// Set attaches the identity to the request, replacing whatever the client sent.
+func Set(r *http.Request, id Identity) {
+	r.Header.Set(UserIDHeader, strconv.Itoa(id.UserID))
+	r.Header.Set(UserRoleHeader, id.Role)
+	if id.Permission != "" {
+		r.Header.Set(PermissionHeader, id.Permission)
+	} else {
+		r.Header.Del(PermissionHeader)
+	}
+	if id.KeyID != 0 {
+		r.Header.Set(KeyIDHeader, strconv.Itoa(id.KeyID))
+	} else {
+		r.Header.Del(KeyIDHeader)
+	}
+	if id.SessionID != 0 {
+		r.Header.Set(SessionIDHeader, strconv.Itoa(id.SessionID))
+	} else {
+		r.Header.Del(SessionIDHeader)
+	}
+}
+
+func Strip(r *http.Request) {
+	r.Header.Del(UserIDHeader)
+	r.Header.Del(UserRoleHeader)
+	r.Header.Del(PermissionHeader)
+	r.Header.Del(KeyIDHeader)
+	r.Header.Del(SessionIDHeader)
+	r.Header.Del(InternalHeader)
+}
+
+// Internal answers 401 to requests that do not carry the internal secret,
+// so the services cannot be called around the gateway. Health and metrics
+// endpoints stay open.
+func Internal(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !openPaths[r.URL.Path] && !ValidSecret(r.Header.Get(InternalHeader), secret) {
+				utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid internal token"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ValidSecret compares got with the internal secret in constant time. An
+// unset secret matches nothing.
+func ValidSecret(got, secret string) bool {
+	return secret != "" && subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
+func (id Identity) IsAdmin() bool {
+	return id.Role == Admin
+}
+
+func (id Identity) IsService() bool {
+	return id.Role == Service
+}
+
+// Restricted reports whether the caller may only access its own records:
+// authenticated non-admin users. The gateway's own calls, API key clients
+// and users let through by a permission of their role are not restricted.
+func Restricted(r *http.Request) (Identity, bool) {
+	id, ok := FromRequest(r)
+	if !ok || !id.IsRestricted() {
+		return id, false
+	}
+
+	return id, true
+}
+
+// IsRestricted reports whether the caller may only access its own records.
+func (id Identity) IsRestricted() bool {
+	return !id.IsAdmin() && !id.IsService() && id.Permission == ""
+}
@@ -0,0 +1,34 @@
+package identity
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestricted(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   bool
+	}{
+		{"gateway call", map[string]string{}, false},
+		{"admin", map[string]string{UserIDHeader: "1", UserRoleHeader: Admin}, false},
+		{"API key client", map[string]string{UserIDHeader: "0", UserRoleHeader: Service, KeyIDHeader: "4"}, false},
+		{"client with a permission", map[string]string{UserIDHeader: "7", UserRoleHeader: "support", PermissionHeader: "orders:read"}, false},
+		{"client", map[string]string{UserIDHeader: "7", UserRoleHeader: Client}, true},
+		{"custom role without a permission", map[string]string{UserIDHeader: "7", UserRoleHeader: "support"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+
+			if _, got := Restricted(r); got != tt.want {
+				t.Errorf("Restricted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
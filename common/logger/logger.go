@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// Init makes a JSON logger tagged with the service name the default slog
+// logger, and routes the standard log package through it as well.
+func Init(service string) *slog.Logger {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: level(configs.Envs.Log_Level),
+	})).With("service", service)
+
+	slog.SetDefault(logger)
+
+	return logger
+}
+
+// Fatal logs the error and exits, like log.Fatal.
+func Fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// FromContext returns the default logger annotated with the request ID and
+// trace ID of the request being served, if any.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+
+	if id := RequestID(ctx); id != "" {
+		logger = logger.With("request_id", id)
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		logger = logger.With("trace_id", span.TraceID().String())
+	}
+
+	return logger
+}
+
+// RequestID returns the request ID stored in the context by Middleware.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func level(s string) slog.Level {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware accepts the caller's X-Request-ID or generates one, passes it
+// on with the request and response, and writes one access log line per
+// request once it is served.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := AcceptRequestID(r.Header.Get(RequestIDHeader))
+
+		r.Header.Set(RequestIDHeader, id)
+		w.Header().Set(RequestIDHeader, id)
+
+		rec := NewRecorder(w)
+		ctx := WithRequestID(r.Context(), id)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		attrs := []any{
+			"method", r.Method,
+			"route", RouteTemplate(r),
+			"path", r.URL.Path,
+			"status", rec.status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", rec.bytes,
+		}
+
+		level := slog.LevelInfo
+		if rec.err != nil {
+			attrs = append(attrs, "error", rec.err.Error())
+		}
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		FromContext(ctx).Log(ctx, level, "request", attrs...)
+	})
+}
+
+// Recorder captures the status, size and handler error of a response.
+type Recorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	err         error
+	wroteHeader bool
+}
+
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (rec *Recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *Recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// RecordError is called by utils.WriteError so handler errors end up in the
+// access log line.
+func (rec *Recorder) RecordError(err error) {
+	rec.err = err
+}
+
+func (rec *Recorder) Status() int {
+	return rec.status
+}
+
+func (rec *Recorder) Flush() {
+	http.NewResponseController(rec.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection over to a WebSocket handler, which answers
+// 101 Switching Protocols on it.
+func (rec *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(rec.ResponseWriter).Hijack()
+	if err == nil {
+		rec.status = http.StatusSwitchingProtocols
+		rec.wroteHeader = true
+	}
+
+	return conn, rw, err
+}
+
+func (rec *Recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// RouteTemplate returns the mux path template the request matched, or the
+// path when it matched none.
+func RouteTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+
+	return r.URL.Path
+}
+
+// AcceptRequestID returns the caller's request ID, or a new one if it sent
+// none or one that is not usable.
+func AcceptRequestID(id string) string {
+	if !validRequestID(id) {
+		return newRequestID()
+	}
+
+	return id
+}
+
+// validRequestID rejects IDs that could break or forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,75 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"os"
+	"strings"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+)
+
+// Message is a plain text email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer selected by MAIL_BACKEND, which must be set.
+func New() (Mailer, error) {
+	switch configs.Envs.Mail_Backend {
+	case "":
+		return nil, fmt.Errorf("MAIL_BACKEND is not set, choose file or smtp")
+	case "file":
+		if configs.Envs.Mail_File == "" {
+			return NewFileMailer(os.Stdout, configs.Envs.Mail_From), nil
+		}
+
+		return OpenFileMailer(configs.Envs.Mail_File, configs.Envs.Mail_From)
+	case "smtp":
+		if configs.Envs.Smtp_Addr == "" {
+			return nil, fmt.Errorf("SMTP_ADDR is not set")
+		}
+
+		return NewSMTPMailer(configs.Envs.Smtp_Addr, configs.Envs.Smtp_Username, configs.Envs.Smtp_Password, configs.Envs.Mail_From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", configs.Envs.Mail_Backend)
+	}
+}
+
+// format renders msg as an RFC 5322 message from the sender.
+func format(from string, msg Message) ([]byte, error) {
+	for _, value := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("invalid mail header %q", value)
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&b)
+	body.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	b.WriteString("\r\n")
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/4lerman/e_com/common/logger"
+)
+
+// Middleware counts and times every request. It reads the status from the
+// logger.Recorder when it runs inside logger.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		rec, ok := w.(*logger.Recorder)
+		if !ok {
+			rec = logger.NewRecorder(w)
+		}
+
+		next.ServeHTTP(rec, r)
+
+		labels := []string{r.Method, logger.RouteTemplate(r), strconv.Itoa(rec.Status())}
+		httpRequests.WithLabelValues(labels...).Inc()
+		httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    keyHash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    expiresAt TIMESTAMP,
+    lastUsedAt TIMESTAMP,
+    revokedAt TIMESTAMP,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    userId INT NOT NULL,
+    orderId INT NOT NULL,
+    data JSONB NOT NULL,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS events_user_id ON events (userId, id);
+CREATE INDEX IF NOT EXISTS events_created_at ON events (createdAt);
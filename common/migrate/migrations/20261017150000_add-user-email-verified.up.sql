@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS emailVerified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- accounts created before verification existed keep placing orders
+UPDATE users SET emailVerified = TRUE;
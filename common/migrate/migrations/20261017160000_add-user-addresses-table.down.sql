@@ -0,0 +1,3 @@
+ALTER TABLE users ALTER COLUMN address TYPE VARCHAR(50) USING left(address, 50);
+
+DROP TABLE IF EXISTS user_addresses;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS user_addresses (
+    id SERIAL PRIMARY KEY,
+    userId INT NOT NULL,
+    label VARCHAR(50) NOT NULL DEFAULT '',
+    country CHAR(2) NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    street VARCHAR(255) NOT NULL,
+    postalCode VARCHAR(20) NOT NULL,
+    phone VARCHAR(20) NOT NULL DEFAULT '',
+    defaultShipping BOOLEAN NOT NULL DEFAULT FALSE,
+    defaultBilling BOOLEAN NOT NULL DEFAULT FALSE,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_addresses_user_id ON user_addresses (userId);
+
+-- a user has at most one default address of each kind
+CREATE UNIQUE INDEX IF NOT EXISTS user_addresses_default_shipping ON user_addresses (userId) WHERE defaultShipping;
+CREATE UNIQUE INDEX IF NOT EXISTS user_addresses_default_billing ON user_addresses (userId) WHERE defaultBilling;
+
+-- the old single-line address stays, with room for a real one
+ALTER TABLE users ALTER COLUMN address TYPE VARCHAR(255);
@@ -0,0 +1,5 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS shippingAddressId,
+    DROP COLUMN IF EXISTS shippingAddress,
+    DROP COLUMN IF EXISTS billingAddressId,
+    DROP COLUMN IF EXISTS billingAddress;
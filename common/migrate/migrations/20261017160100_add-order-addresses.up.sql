@@ -0,0 +1,7 @@
+-- orders keep a copy of the addresses they were placed with, so editing or
+-- deleting an address does not change them
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS shippingAddressId INT REFERENCES user_addresses(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS shippingAddress JSONB,
+    ADD COLUMN IF NOT EXISTS billingAddressId INT REFERENCES user_addresses(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS billingAddress JSONB;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS data_requests (
+    id SERIAL PRIMARY KEY,
+    userId INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    requestedBy INT,
+    requestedByRole VARCHAR(20),
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS data_requests_user_id ON data_requests (userId);
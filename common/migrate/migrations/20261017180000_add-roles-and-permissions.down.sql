@@ -0,0 +1,9 @@
+-- users of custom roles fall back to clients
+CREATE TYPE role_type AS ENUM ('admin', 'client');
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_user_role;
+ALTER TABLE users ALTER COLUMN userRole TYPE role_type
+    USING (CASE WHEN userRole = 'admin' THEN 'admin' ELSE 'client' END)::role_type;
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
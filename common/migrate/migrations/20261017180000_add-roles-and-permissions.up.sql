@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    builtIn BOOLEAN NOT NULL DEFAULT FALSE,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    roleId INT NOT NULL,
+    permissionId INT NOT NULL,
+
+    PRIMARY KEY (roleId, permissionId),
+    FOREIGN KEY (roleId) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permissionId) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+INSERT INTO roles (name, description, builtIn) VALUES
+    ('admin', 'Full access to everything', TRUE),
+    ('client', 'Customers, who manage their own account, orders and payments', TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+    ('user:read', 'Read any user'),
+    ('user:create', 'Create users'),
+    ('user:update', 'Update any user'),
+    ('user:delete', 'Erase any user'),
+    ('product:read', 'Read products'),
+    ('product:create', 'Create products'),
+    ('product:update', 'Update products, including stock'),
+    ('product:delete', 'Delete products'),
+    ('order:read', 'Read any order'),
+    ('order:create', 'Create orders and add items for any user'),
+    ('order:update', 'Update any order'),
+    ('order:delete', 'Delete any order'),
+    ('payment:read', 'Read any payment'),
+    ('payment:create', 'Create payments for any order'),
+    ('payment:update', 'Update any payment'),
+    ('payment:delete', 'Delete any payment')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (roleId, permissionId)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions WHERE roles.name = 'admin'
+ON CONFLICT DO NOTHING;
+
+-- existing users keep their role, which now names a row of roles
+ALTER TABLE users ALTER COLUMN userRole TYPE VARCHAR(50) USING userRole::text;
+ALTER TABLE users ADD CONSTRAINT users_user_role FOREIGN KEY (userRole) REFERENCES roles(name) ON UPDATE CASCADE;
+DROP TYPE IF EXISTS role_type;
@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name IN ('session:read', 'session:delete');
+
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id SERIAL PRIMARY KEY,
+    userId INT NOT NULL,
+    device VARCHAR(100) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    userAgent VARCHAR(255) NOT NULL DEFAULT '',
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lastSeenAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expiresAt TIMESTAMP NOT NULL,
+    revokedAt TIMESTAMP,
+    revokedReason VARCHAR(50),
+
+    FOREIGN KEY (userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id ON sessions (userId);
+
+-- every refresh token a session was given; used ones are kept to detect reuse
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    sessionId INT NOT NULL,
+    tokenHash CHAR(64) NOT NULL UNIQUE,
+    usedAt TIMESTAMP,
+    createdAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (sessionId) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_id ON refresh_tokens (sessionId);
+
+INSERT INTO permissions (name, description) VALUES
+    ('session:read', 'List the sessions of any user'),
+    ('session:delete', 'Revoke the sessions of any user')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (roleId, permissionId)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name IN ('session:read', 'session:delete')
+ON CONFLICT DO NOTHING;
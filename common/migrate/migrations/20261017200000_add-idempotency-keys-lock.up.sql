@@ -0,0 +1,2 @@
+-- keys held by requests that never completed are taken over once this passes
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS lockedUntil TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
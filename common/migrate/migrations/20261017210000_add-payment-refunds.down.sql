@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name = 'payment:refund';
+
+-- enum values cannot be dropped; refunded payments count as successful again
+UPDATE payments SET status = 'success' WHERE status = 'refunded';
@@ -0,0 +1,10 @@
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'refunded';
+
+INSERT INTO permissions (name, description) VALUES
+    ('payment:refund', 'Refund any successful payment')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (roleId, permissionId)
+SELECT roles.id, permissions.id FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin' AND permissions.name = 'payment:refund'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,170 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey carries the request ID in gRPC metadata, like the
+// X-Request-ID header does over HTTP.
+const requestIDKey = "x-request-id"
+
+// secretKey carries INTERNAL_SECRET in gRPC metadata, like the
+// X-Internal-Token header does over HTTP.
+const secretKey = "x-internal-token"
+
+// NewServer returns a gRPC server that traces every call and writes one
+// access log line per call, like the HTTP middleware does. It only serves
+// calls carrying the internal secret, since the stores behind it apply no
+// ownership rules of their own.
+func NewServer() *grpc.Server {
+	return grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(accessLog, authenticate),
+	)
+}
+
+// Serve serves the internal gRPC API on port until the server is stopped.
+func Serve(server *grpc.Server, port string) error {
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return fmt.Errorf("failed to listen on grpc port %s: %w", port, err)
+	}
+
+	slog.Info("grpc server is starting", "port", port)
+
+	return server.Serve(listener)
+}
+
+// Dial returns a connection to the gRPC API of the service at addr. It
+// connects lazily, passes the trace context and request ID on and sends
+// the internal secret with every call.
+func Dial(addr string) (*grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(passRequestID, passSecret),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grpc address %q: %w", addr, err)
+	}
+
+	return conn, nil
+}
+
+// Error turns a store error into a gRPC status: NotFound for any of the
+// store's notFound errors, Internal otherwise.
+func Error(err error, notFound ...error) error {
+	for _, target := range notFound {
+		if errors.Is(err, target) {
+			return status.Error(codes.NotFound, err.Error())
+		}
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
+
+// ErrNotFound matches the errors FromError returns for NotFound statuses.
+var ErrNotFound = errors.New("not found")
+
+// FromError turns a gRPC status back into a plain error with the message
+// the store reported, so callers see the same errors as with a local store.
+func FromError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	if s.Code() == codes.NotFound {
+		return notFoundError(s.Message())
+	}
+
+	return errors.New(s.Message())
+}
+
+type notFoundError string
+
+func (e notFoundError) Error() string {
+	return string(e)
+}
+
+func (e notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func accessLog(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	ctx = logger.WithRequestID(ctx, logger.AcceptRequestID(id))
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	attrs := []any{
+		"method", info.FullMethod,
+		"code", code.String(),
+		"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	level := slog.LevelInfo
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+
+	logger.FromContext(ctx).Log(ctx, level, "grpc request", attrs...)
+
+	return resp, err
+}
+
+// authenticate refuses calls without the internal secret.
+func authenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var secret string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(secretKey); len(values) > 0 {
+			secret = values[0]
+		}
+	}
+
+	if !identity.ValidSecret(secret, configs.Envs.Internal_Secret) {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid internal token")
+	}
+
+	return handler(ctx, req)
+}
+
+func passSecret(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, secretKey, configs.Envs.Internal_Secret)
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func passRequestID(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := logger.RequestID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestIDKey, id)
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/4lerman/e_com/common/logger"
+)
+
+// PrefersRepresentation reports whether the client asked, with
+// "Prefer: return=representation" (RFC 7240), to get the resource it
+// created back instead of a status message.
+func PrefersRepresentation(r *http.Request) bool {
+	for _, prefer := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(prefer, ",") {
+			if strings.EqualFold(strings.TrimSpace(preference), "return=representation") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// WriteCreated answers a create with 201 and a Location header. The body is
+// the new resource, loaded by load, if the client prefers it, and the usual
+// message otherwise. The resource is committed by then, so when load fails
+// the client still gets 201 and the message, without Preference-Applied,
+// and can follow Location.
+func WriteCreated(w http.ResponseWriter, r *http.Request, location string, load func() (any, error)) {
+	w.Header().Set("Location", location)
+
+	created := map[string]string{"msg": "Created successfully"}
+
+	if !PrefersRepresentation(r) {
+		WriteJSON(w, http.StatusCreated, created)
+		return
+	}
+
+	v, err := load()
+	if err != nil {
+		logger.FromContext(r.Context()).Error("failed to load created resource", "location", location, "error", err)
+		WriteJSON(w, http.StatusCreated, created)
+		return
+	}
+
+	w.Header().Set("Preference-Applied", "return=representation")
+	WriteJSON(w, http.StatusCreated, v)
+}
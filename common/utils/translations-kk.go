@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"reflect"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// kazakhMessages are the Kazakh validation messages. The validator ships
+// no Kazakh translations, so the rules the API uses and the common ones are
+// translated here; length and range rules read differently for strings,
+// collections and numbers.
+var kazakhMessages = map[string]map[string]string{
+	"required": {"": "{0} міндетті өріс"},
+	"email":    {"": "{0} жарамды email мекенжайы болуы керек"},
+	"url":      {"": "{0} жарамды URL болуы керек"},
+	"numeric":  {"": "{0} сан болуы керек"},
+	"oneof":    {"": "{0} келесі мәндердің бірі болуы керек: {1}"},
+	"len": {
+		"string": "{0} ұзындығы {1} таңба болуы керек",
+		"items":  "{0} {1} элементтен тұруы керек",
+		"number": "{0} {1} мәніне тең болуы керек",
+	},
+	"min": {
+		"string": "{0} кемінде {1} таңбадан тұруы керек",
+		"items":  "{0} кемінде {1} элементтен тұруы керек",
+		"number": "{0} {1} мәнінен кем болмауы керек",
+	},
+	"max": {
+		"string": "{0} ең көбі {1} таңбадан тұруы керек",
+		"items":  "{0} ең көбі {1} элементтен тұруы керек",
+		"number": "{0} {1} мәнінен аспауы керек",
+	},
+	"gt": {
+		"string": "{0} {1} таңбадан ұзын болуы керек",
+		"items":  "{0} {1} элементтен көп болуы керек",
+		"number": "{0} {1} мәнінен үлкен болуы керек",
+	},
+	"gte": {
+		"string": "{0} кемінде {1} таңбадан тұруы керек",
+		"items":  "{0} кемінде {1} элементтен тұруы керек",
+		"number": "{0} {1} мәнінен кем болмауы керек",
+	},
+	"lt": {
+		"string": "{0} {1} таңбадан қысқа болуы керек",
+		"items":  "{0} {1} элементтен аз болуы керек",
+		"number": "{0} {1} мәнінен кіші болуы керек",
+	},
+	"lte": {
+		"string": "{0} ең көбі {1} таңбадан тұруы керек",
+		"items":  "{0} ең көбі {1} элементтен тұруы керек",
+		"number": "{0} {1} мәнінен аспауы керек",
+	},
+}
+
+func registerKazakhTranslations(v *validator.Validate, trans ut.Translator) error {
+	for tag, messages := range kazakhMessages {
+		register := func(trans ut.Translator) error {
+			for kind, message := range messages {
+				if err := trans.Add(messageKey(tag, kind), message, false); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		translate := func(trans ut.Translator, fe validator.FieldError) string {
+			kind := ""
+			if _, ok := messages[""]; !ok {
+				kind = kindOf(fe)
+			}
+
+			message, err := trans.T(messageKey(tag, kind), fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+
+			return message
+		}
+
+		if err := v.RegisterTranslation(tag, trans, register, translate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func messageKey(tag, kind string) string {
+	if kind == "" {
+		return tag
+	}
+
+	return tag + "-" + kind
+}
+
+// kindOf tells whether a length or range rule applies to a string, a
+// collection or a number.
+func kindOf(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	default:
+		return "number"
+	}
+}
@@ -1,6 +1,209 @@
 package utils
 
-import "github.com/go-playground/validator/v10"
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/kk"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
+)
 
 var Validate = validator.New()
+
+// translators hold the validation messages in every language the API
+// speaks, English being the fallback.
+var translators = ut.New(en.New(), en.New(), ru.New(), kk.New())
+
+// validationTitles are the titles of validation problems per language.
+var validationTitles = map[string]string{
+	"en": "Invalid request payload",
+	"ru": "Некорректные данные запроса",
+	"kk": "Сұрау деректері жарамсыз",
+}
+
+// typeMessages report a field whose JSON value has the wrong type, which
+// the decoder catches before the validator.
+var typeMessages = map[string]string{
+	"en": "{0} has an invalid type",
+	"ru": "{0} имеет неверный тип",
+	"kk": "{0} түрі жарамсыз",
+}
+
+func init() {
+	// report fields by the names clients send them with
+	Validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	english, _ := translators.GetTranslator("en")
+	russian, _ := translators.GetTranslator("ru")
+	kazakh, _ := translators.GetTranslator("kk")
+
+	for _, err := range []error{
+		enTranslations.RegisterDefaultTranslations(Validate, english),
+		ruTranslations.RegisterDefaultTranslations(Validate, russian),
+		registerKazakhTranslations(Validate, kazakh),
+	} {
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	for _, trans := range []ut.Translator{english, russian, kazakh} {
+		if err := trans.Add("invalid-type", typeMessages[trans.Locale()], false); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Translator returns the translator of the language the client prefers
+// most in its Accept-Language header, English if it speaks none of ours.
+func Translator(r *http.Request) ut.Translator {
+	trans, _ := translators.FindTranslator(acceptedLanguages(r.Header.Get("Accept-Language"))...)
+	return trans
+}
+
+// WriteValidationError answers 400 with a validation problem for a payload
+// that could not be decoded or failed validation. Each invalid field is
+// listed by its JSON name, the rule it broke and a message in the client's
+// language.
+func WriteValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	if rec, ok := w.(interface{ RecordError(error) }); ok {
+		rec.RecordError(err)
+	}
+
+	trans := Translator(r)
+	problem := Problem{
+		Type:   ValidationProblem,
+		Title:  validationTitles[trans.Locale()],
+		Status: http.StatusBadRequest,
+	}
+
+	var invalid validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &invalid):
+		for _, fe := range invalid {
+			problem.Errors = append(problem.Errors, FieldError{
+				Field:   fieldPath(fe),
+				Code:    fe.Tag(),
+				Message: translate(fe, trans),
+			})
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		message, _ := trans.T("invalid-type", typeErr.Field)
+		problem.Errors = []FieldError{{Field: typeErr.Field, Code: "type", Message: message}}
+	default:
+		problem.Detail = err.Error()
+	}
+
+	writeValidationProblem(w, trans, problem)
+}
+
+// WriteFieldErrors answers 400 with a validation problem listing fields,
+// for requests checked by something other than Validate. err is logged.
+func WriteFieldErrors(w http.ResponseWriter, r *http.Request, err error, fields []FieldError) {
+	if rec, ok := w.(interface{ RecordError(error) }); ok {
+		rec.RecordError(err)
+	}
+
+	trans := Translator(r)
+	writeValidationProblem(w, trans, Problem{
+		Type:   ValidationProblem,
+		Title:  validationTitles[trans.Locale()],
+		Status: http.StatusBadRequest,
+		Errors: fields,
+	})
+}
+
+func writeValidationProblem(w http.ResponseWriter, trans ut.Translator, problem Problem) {
+	if problem.Detail == "" {
+		messages := make([]string, len(problem.Errors))
+		for i, fieldErr := range problem.Errors {
+			messages[i] = fieldErr.Message
+		}
+		problem.Detail = strings.Join(messages, "; ")
+	}
+
+	w.Header().Set("Content-Language", trans.Locale())
+	WriteProblem(w, problem)
+}
+
+// translate falls back to English for rules the language has no message
+// for.
+func translate(fe validator.FieldError, trans ut.Translator) string {
+	if message := fe.Translate(trans); message != fe.Error() {
+		return message
+	}
+
+	english, _ := translators.GetTranslator("en")
+	return fe.Translate(english)
+}
+
+// fieldPath is the dotted JSON path of the field below the payload.
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+
+	return path
+}
+
+// acceptedLanguages lists the primary language tags of an Accept-Language
+// header from the most to the least preferred.
+func acceptedLanguages(header string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag, _, _ = strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		languages = append(languages, language{tag: tag, q: q})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].q > languages[j].q
+	})
+
+	tags := make([]string, len(languages))
+	for i, language := range languages {
+		tags[i] = language.tag
+	}
+
+	return tags
+}
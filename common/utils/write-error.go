@@ -1,7 +1,49 @@
 package utils
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+)
 
-func WriteError(r http.ResponseWriter, status int, err error) {
-	WriteJSON(r, status, map[string]string{"error": err.Error()})
+// ValidationProblem is the problem type of requests whose payload could not
+// be decoded or failed validation; its Errors list the offending fields.
+const ValidationProblem = "/problems/validation-error"
+
+// Problem is an RFC 7807 problem details object, the body of every error
+// response.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+} // @name Problem
+
+// FieldError is a payload field that failed validation. Field is the JSON
+// name, Code the validation rule and Message a translation for the client.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+} // @name FieldError
+
+func WriteError(w http.ResponseWriter, status int, err error) {
+	// let the access log middleware log the error along with the request
+	if rec, ok := w.(interface{ RecordError(error) }); ok {
+		rec.RecordError(err)
+	}
+
+	WriteProblem(w, Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+	})
+}
+
+func WriteProblem(w http.ResponseWriter, problem Problem) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+
+	return json.NewEncoder(w).Encode(problem)
 }
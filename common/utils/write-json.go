@@ -2,7 +2,6 @@ package utils
 
 import (
 	"encoding/json"
-	"io"
 	"net/http"
 )
 
@@ -12,14 +11,3 @@ func WriteJSON(w http.ResponseWriter, status int, v any) error {
 
 	return json.NewEncoder(w).Encode(v)
 }
-
-func ResCopy(w http.ResponseWriter, status int, resp *http.Response) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(resp.StatusCode)
-
-	_, err := io.Copy(w, resp.Body)
-	if err != nil {
-		return err
-	}
-	return nil
-}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,54 +11,115 @@ import (
 
 	configs "github.com/4lerman/e_com/common/config"
 	"github.com/4lerman/e_com/common/db"
+	"github.com/4lerman/e_com/common/health"
+	"github.com/4lerman/e_com/common/idempotency"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/metrics"
+	commonRpc "github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/common/tracing"
 	"github.com/4lerman/e_com/order/routes"
+	orderRpc "github.com/4lerman/e_com/order/rpc"
 	orderStore "github.com/4lerman/e_com/order/store"
-	productStore "github.com/4lerman/e_com/product/store"
+	productRpc "github.com/4lerman/e_com/product/rpc"
+	orderv1 "github.com/4lerman/e_com/proto/order/v1"
+	userRpc "github.com/4lerman/e_com/user/rpc"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"google.golang.org/grpc"
 )
 
 func main() {
+	logger.Init("orders")
+
+	if err := configs.CheckSecret("INTERNAL_SECRET", configs.Envs.Internal_Secret); err != nil {
+		logger.Fatal("invalid configuration", err)
+	}
+
+	shutdownTracing, err := tracing.Init("orders")
+	if err != nil {
+		logger.Fatal("tracing setup failed", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := db.InitStorage()
 	if err != nil {
-		log.Fatal(err)
+		logger.Fatal("db connection failed", err)
 	}
 
-	log.Println("Db connected successfully!")
+	slog.Info("db connected")
+	metrics.RegisterDB(db, "orders")
 
 	orderStore := orderStore.NewStore(db)
-	productStore := productStore.NewStore(db)
-	orderHandler := routes.NewHandler(orderStore, productStore)
+
+	// products belong to the product service, they are read and reserved
+	// through its gRPC API
+	productConn, err := commonRpc.Dial(configs.Envs.Products_Grpc_Addr)
+	if err != nil {
+		logger.Fatal("product service client setup failed", err)
+	}
+	defer productConn.Close()
+
+	productStore := productRpc.NewClient(productConn)
+
+	// orders are only taken from users with a verified email, which the
+	// user service knows
+	userConn, err := commonRpc.Dial(configs.Envs.Users_Grpc_Addr)
+	if err != nil {
+		logger.Fatal("user service client setup failed", err)
+	}
+	defer userConn.Close()
+
+	userStore := userRpc.NewClient(userConn)
+
+	idempotencyStore := idempotency.NewStore(db, time.Duration(configs.Envs.Idempotency_Key_Ttl)*time.Second, time.Duration(configs.Envs.Idempotency_Lock_Ttl)*time.Second)
+	go idempotencyStore.PurgeEvery(time.Hour)
+
+	orderHandler := routes.NewHandler(orderStore, productStore, userStore, idempotencyStore)
 
 	router := mux.NewRouter()
+	router.Use(tracing.Middleware, logger.Middleware, metrics.Middleware, identity.Internal(configs.Envs.Internal_Secret))
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	health.Register(router, map[string]health.Check{
+		"db": health.DB(db),
+	})
+
 	orderRouter := router.PathPrefix("/orders").Subrouter()
 	orderHandler.RegisterRoutes(orderRouter)
 
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{configs.Envs.Base_Url},		
+		AllowedOrigins:   []string{configs.Envs.Base_Url},
 		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
-		AllowedHeaders:   []string{"Content-Type"},
+		AllowedHeaders:   []string{"Content-Type", idempotency.Header},
 		AllowCredentials: true,
-	}).Handler(orderRouter)
+	}).Handler(router)
 
 	port := configs.Envs.Orders_Port
 	server := &http.Server{
-		Addr:   ":" + port,
+		Addr:    ":" + port,
 		Handler: corsHandler,
 	}
 
+	grpcServer := commonRpc.NewServer()
+	orderv1.RegisterOrderStoreServer(grpcServer, orderRpc.NewServer(orderStore))
 
-	go gracefulShutdown(server)
+	go func() {
+		if err := commonRpc.Serve(grpcServer, configs.Envs.Orders_Grpc_Port); err != nil {
+			logger.Fatal("grpc server startup failed", err)
+		}
+	}()
 
-	log.Printf("Server(Orders) is starting on port %s\n", port)
+	go gracefulShutdown(server, grpcServer)
+
+	slog.Info("server is starting", "port", port)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server startup failed: %v\n", err)
+		logger.Fatal("server startup failed", err)
 	}
 
-	log.Println("Server(Orders) gracefully stopped")
+	slog.Info("server gracefully stopped")
 }
 
-func gracefulShutdown(server *http.Server) {
+func gracefulShutdown(server *http.Server, grpcServer *grpc.Server) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
@@ -68,6 +129,8 @@ func gracefulShutdown(server *http.Server) {
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Graceful shutdown failed: %v\n", err)
+		logger.Fatal("graceful shutdown failed", err)
 	}
+
+	grpcServer.GracefulStop()
 }
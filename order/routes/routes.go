@@ -5,37 +5,51 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/4lerman/e_com/common/idempotency"
+	"github.com/4lerman/e_com/common/identity"
 	"github.com/4lerman/e_com/common/utils"
 	orderTypes "github.com/4lerman/e_com/order/types"
 	productTypes "github.com/4lerman/e_com/product/types"
-	"github.com/go-playground/validator/v10"
+	userTypes "github.com/4lerman/e_com/user/types"
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
 	store        orderTypes.OrderStore
 	productStore productTypes.ProductStore
+	userStore    orderTypes.UserReader
+	idempotency  *idempotency.Store
 }
 
-func NewHandler(store orderTypes.OrderStore, productStore productTypes.ProductStore) *Handler {
+func NewHandler(store orderTypes.OrderStore, productStore productTypes.ProductStore, userStore orderTypes.UserReader, idempotencyStore *idempotency.Store) *Handler {
 	return &Handler{
 		store:        store,
 		productStore: productStore,
+		userStore:    userStore,
+		idempotency:  idempotencyStore,
 	}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("", h.handleListOrders).Methods(http.MethodGet)
-	router.HandleFunc("", h.handleCreateOrder).Methods(http.MethodPost)
+	router.Handle("", idempotency.Middleware(h.idempotency, http.HandlerFunc(h.handleCreateOrder))).Methods(http.MethodPost)
 	router.HandleFunc("/search", h.handleOrderByStatusOrUser).Methods(http.MethodGet)
 	router.HandleFunc("/{id}", h.handleGetOrderById).Methods(http.MethodGet)
 	router.HandleFunc("/{id}", h.handleUpdateOrder).Methods(http.MethodPut)
 	router.HandleFunc("/{id}", h.handleDeleteOrder).Methods(http.MethodDelete)
-	router.HandleFunc("/{id}/order", h.handleCreateOrderItem).Methods(http.MethodPost)
+	router.Handle("/{id}/order", idempotency.Middleware(h.idempotency, http.HandlerFunc(h.handleCreateOrderItem))).Methods(http.MethodPost)
+	router.HandleFunc("/{id}/items", h.handleGetOrderItems).Methods(http.MethodGet)
 }
 
 func (h *Handler) handleListOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.store.ListOrders()
+	var orders []orderTypes.Order
+	var err error
+
+	if caller, restricted := identity.Restricted(r); restricted {
+		orders, err = h.store.GetOrdersByUserId(r.Context(), caller.UserID)
+	} else {
+		orders, err = h.store.ListOrders(r.Context())
+	}
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -48,20 +62,54 @@ func (h *Handler) handleListOrders(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
 	var payload orderTypes.CreateOrderPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
+	// clients can only place orders for themselves
+	if caller, restricted := identity.Restricted(r); restricted {
+		payload.UserID = caller.UserID
+	}
+
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	err := h.store.CreateOrder(orderTypes.Order{
-		UserID: payload.UserID,
-		Total:  payload.Total,
-		Status: payload.Status,
+	user, err := h.userStore.GetUserById(r.Context(), payload.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !user.EmailVerified {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("email %s must be verified before placing orders", user.Email))
+		return
+	}
+
+	addresses, err := h.userStore.ListAddresses(r.Context(), payload.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shipping, err := chooseAddress(addresses, payload.ShippingAddressID, func(address userTypes.Address) bool { return address.DefaultShipping })
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid shipping address: %w", err))
+		return
+	}
+
+	billing, err := chooseAddress(addresses, payload.BillingAddressID, func(address userTypes.Address) bool { return address.DefaultBilling })
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid billing address: %w", err))
+		return
+	}
+
+	id, err := h.store.CreateOrder(r.Context(), orderTypes.Order{
+		UserID:          payload.UserID,
+		Status:          orderTypes.New,
+		ShippingAddress: shipping,
+		BillingAddress:  billing,
 	})
 
 	if err != nil {
@@ -69,7 +117,9 @@ func (h *Handler) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, map[string]string{"msg": "Created successfully"})
+	utils.WriteCreated(w, r, fmt.Sprintf("/orders/%d", id), func() (any, error) {
+		return h.store.GetOrderById(r.Context(), id)
+	})
 
 }
 
@@ -84,9 +134,9 @@ func (h *Handler) handleGetOrderById(w http.ResponseWriter, r *http.Request) {
 
 	orderId, _ := strconv.Atoi(id)
 
-	order, err := h.store.GetOrderById(orderId)
-	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get order by id: %v", err))
+	order, err := h.store.GetOrderById(r.Context(), orderId)
+	if err != nil || !canAccess(r, order) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get order by id: %v", errOrNotFound(err)))
 		return
 	}
 
@@ -106,21 +156,40 @@ func (h *Handler) handleUpdateOrder(w http.ResponseWriter, r *http.Request) {
 
 	var payload orderTypes.UpdateOrderPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	err := h.store.UpdateOrder(orderId, orderTypes.Order{
-		UserID: payload.UserID,
-		Total:  payload.Total,
-		Status: payload.Status,
-	})
+	// the status follows payments and fulfilment and the total the items,
+	// so clients cannot set either, nor hand their orders to someone else
+	if caller, restricted := identity.Restricted(r); restricted &&
+		(payload.Status != "" || payload.Total != 0 || (payload.UserID != 0 && payload.UserID != caller.UserID)) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("clients cannot change the status, total or user of an order"))
+		return
+	}
+
+	order, err := h.store.GetOrderById(r.Context(), orderId)
+	if err != nil || !canAccess(r, order) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get order by id: %v", errOrNotFound(err)))
+		return
+	}
+
+	if payload.UserID != 0 {
+		order.UserID = payload.UserID
+	}
+	if payload.Total != 0 {
+		order.Total = payload.Total
+	}
+	if payload.Status != "" {
+		order.Status = payload.Status
+	}
+
+	err = h.store.UpdateOrder(r.Context(), orderId, *order)
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -141,7 +210,7 @@ func (h *Handler) handleDeleteOrder(w http.ResponseWriter, r *http.Request) {
 
 	orderId, _ := strconv.Atoi(id)
 
-	if err := h.store.DeleteOrder(orderId); err != nil {
+	if err := h.store.DeleteOrder(r.Context(), orderId); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -157,10 +226,10 @@ func (h *Handler) handleOrderByStatusOrUser(w http.ResponseWriter, r *http.Reque
 	var err error
 
 	if status != "" {
-		orders, err = h.store.GetOrdersByStatus(status)
+		orders, err = h.store.GetOrdersByStatus(r.Context(), status)
 	} else if user != "" {
 		userId, _ := strconv.Atoi(user)
-		orders, err = h.store.GetOrdersByUserId(userId)
+		orders, err = h.store.GetOrdersByUserId(r.Context(), userId)
 	} else {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("either status or user query parameter is required"))
 		return
@@ -171,6 +240,16 @@ func (h *Handler) handleOrderByStatusOrUser(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if _, restricted := identity.Restricted(r); restricted {
+		own := []orderTypes.Order{}
+		for _, order := range orders {
+			if canAccess(r, &order) {
+				own = append(own, order)
+			}
+		}
+		orders = own
+	}
+
 	utils.WriteJSON(w, http.StatusOK, orders)
 }
 
@@ -187,17 +266,22 @@ func (h *Handler) handleCreateOrderItem(w http.ResponseWriter, r *http.Request)
 
 	var payload orderTypes.CreateOrderItemPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	order, err := h.store.GetOrderById(r.Context(), orderId)
+	if err != nil || !canAccess(r, order) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get order by id: %v", errOrNotFound(err)))
 		return
 	}
 
-	product, err := h.productStore.GetProductByID(payload.ProductID)
+	product, err := h.productStore.GetProductByID(r.Context(), payload.ProductID)
 
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
@@ -209,7 +293,7 @@ func (h *Handler) handleCreateOrderItem(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.productStore.UpdateProduct(payload.ProductID, productTypes.Product{
+	err = h.productStore.UpdateProduct(r.Context(), payload.ProductID, productTypes.Product{
 		Name:        product.Name,
 		Description: product.Description,
 		Price:       product.Price,
@@ -221,27 +305,36 @@ func (h *Handler) handleCreateOrderItem(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.store.CreateOrderItem(orderTypes.OrderItem{
+	item := orderTypes.OrderItem{
 		OrderID:   orderId,
 		ProductID: payload.ProductID,
 		Quantity:  payload.Quantity,
-	})
+		Price:     product.Price,
+	}
+
+	item.ID, err = h.store.CreateOrderItem(r.Context(), item)
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	totalOrder, err := h.store.GetOrderById(orderId)
+	// the total is always that of the items, never what a client says
+	items, err := h.store.GetOrderItems(r.Context(), orderId)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = h.store.UpdateOrder(orderId, orderTypes.Order{
-		UserID: totalOrder.UserID,
-		Status: totalOrder.Status,
-		Total:  totalOrder.Total + float64(payload.Quantity)*product.Price,
+	total := 0.0
+	for _, ordered := range items {
+		total += float64(ordered.Quantity) * ordered.Price
+	}
+
+	err = h.store.UpdateOrder(r.Context(), orderId, orderTypes.Order{
+		UserID: order.UserID,
+		Status: order.Status,
+		Total:  total,
 	})
 
 	if err != nil {
@@ -249,6 +342,76 @@ func (h *Handler) handleCreateOrderItem(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, map[string]string{"msg": "Created successfully"})
+	utils.WriteCreated(w, r, fmt.Sprintf("/orders/%d/items", orderId), func() (any, error) {
+		items, err := h.store.GetOrderItems(r.Context(), orderId)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, created := range items {
+			if created.ID == item.ID {
+				return created, nil
+			}
+		}
+
+		return item, nil
+	})
+
+}
+
+func (h *Handler) handleGetOrderItems(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if id == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("id is not indicated"))
+		return
+	}
+
+	orderId, _ := strconv.Atoi(id)
+
+	order, err := h.store.GetOrderById(r.Context(), orderId)
+	if err != nil || !canAccess(r, order) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get order by id: %v", errOrNotFound(err)))
+		return
+	}
+
+	items, err := h.store.GetOrderItems(r.Context(), orderId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, items)
+}
+
+// canAccess reports whether the caller may see the order: admins and
+// internal calls see every order, clients only their own.
+func canAccess(r *http.Request, order *orderTypes.Order) bool {
+	caller, restricted := identity.Restricted(r)
+	return !restricted || order.UserID == caller.UserID
+}
+
+// chooseAddress snapshots the user's address with the id, or the default
+// one when id is 0. Users without addresses order without them.
+func chooseAddress(addresses []userTypes.Address, id int, isDefault func(userTypes.Address) bool) (*orderTypes.AddressSnapshot, error) {
+	for _, address := range addresses {
+		if (id == 0 && isDefault(address)) || address.ID == id {
+			return orderTypes.NewAddressSnapshot(address), nil
+		}
+	}
+
+	if id == 0 {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("address %d not found", id)
+}
+
+func errOrNotFound(err error) error {
+	if err != nil {
+		return err
+	}
 
+	return orderTypes.ErrOrderNotFound
 }
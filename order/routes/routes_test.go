@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/4lerman/e_com/common/identity"
+	orderTypes "github.com/4lerman/e_com/order/types"
+	userTypes "github.com/4lerman/e_com/user/types"
+	"github.com/gorilla/mux"
+)
+
+// memoryStore keeps the orders it is given.
+type memoryStore struct {
+	orderTypes.OrderStore
+	orders map[int]orderTypes.Order
+}
+
+func (s *memoryStore) CreateOrder(_ context.Context, order orderTypes.Order) (int, error) {
+	order.ID = len(s.orders) + 1
+	s.orders[order.ID] = order
+	return order.ID, nil
+}
+
+func (s *memoryStore) GetOrderById(_ context.Context, id int) (*orderTypes.Order, error) {
+	order, ok := s.orders[id]
+	if !ok {
+		return nil, orderTypes.ErrOrderNotFound
+	}
+	return &order, nil
+}
+
+func (s *memoryStore) UpdateOrder(_ context.Context, id int, order orderTypes.Order) error {
+	order.ID = id
+	s.orders[id] = order
+	return nil
+}
+
+type userReader struct{}
+
+func (userReader) GetUserById(_ context.Context, id int) (*userTypes.User, error) {
+	return &userTypes.User{ID: id, EmailVerified: true}, nil
+}
+
+func (userReader) ListAddresses(context.Context, int) ([]userTypes.Address, error) {
+	return nil, nil
+}
+
+func serve(h *Handler, method, path, body string, caller identity.Identity) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	h.RegisterRoutes(router.PathPrefix("/orders").Subrouter())
+
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	identity.Set(r, caller)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestCreateOrderIgnoresClientStatusAndTotal(t *testing.T) {
+	store := &memoryStore{orders: map[int]orderTypes.Order{}}
+	h := NewHandler(store, nil, userReader{}, nil)
+
+	w := serve(h, http.MethodPost, "/orders", `{"user_id":8,"total":0.01,"status":"done"}`, identity.Identity{UserID: 7, Role: identity.Client})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+
+	order := store.orders[1]
+	if order.UserID != 7 || order.Status != orderTypes.New || order.Total != 0 {
+		t.Errorf("created %+v, want a new, empty order of user 7", order)
+	}
+}
+
+func TestUpdateOrder(t *testing.T) {
+	client := identity.Identity{UserID: 7, Role: identity.Client}
+	admin := identity.Identity{UserID: 1, Role: identity.Admin}
+
+	tests := []struct {
+		name   string
+		caller identity.Identity
+		body   string
+		want   int
+		after  orderTypes.Order
+	}{
+		{"client sets the status", client, `{"status":"done"}`, http.StatusForbidden, orderTypes.Order{UserID: 7, Total: 50, Status: orderTypes.New}},
+		{"client sets the total", client, `{"total":1}`, http.StatusForbidden, orderTypes.Order{UserID: 7, Total: 50, Status: orderTypes.New}},
+		{"client hands the order over", client, `{"user_id":8}`, http.StatusForbidden, orderTypes.Order{UserID: 7, Total: 50, Status: orderTypes.New}},
+		{"admin sets the status", admin, `{"status":"done"}`, http.StatusOK, orderTypes.Order{UserID: 7, Total: 50, Status: orderTypes.Done}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{orders: map[int]orderTypes.Order{
+				1: {ID: 1, UserID: 7, Total: 50, Status: orderTypes.New},
+			}}
+			h := NewHandler(store, nil, userReader{}, nil)
+
+			w := serve(h, http.MethodPut, "/orders/1", tt.body, tt.caller)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+
+			tt.after.ID = 1
+			if got := store.orders[1]; got != tt.after {
+				t.Errorf("order = %+v, want %+v", got, tt.after)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/order/types"
+	orderv1 "github.com/4lerman/e_com/proto/order/v1"
+	"google.golang.org/grpc"
+)
+
+// Client is a types.OrderStore backed by the order service's gRPC API, for
+// services that need orders without sharing its database.
+type Client struct {
+	client orderv1.OrderStoreClient
+}
+
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{
+		client: orderv1.NewOrderStoreClient(conn),
+	}
+}
+
+func (c *Client) CreateOrder(ctx context.Context, order types.Order) (int, error) {
+	resp, err := c.client.CreateOrder(ctx, &orderv1.CreateOrderRequest{Order: toOrder(order)})
+	if err != nil {
+		return 0, rpc.FromError(err)
+	}
+
+	return int(resp.GetId()), nil
+}
+
+func (c *Client) CreateOrderItem(ctx context.Context, item types.OrderItem) (int, error) {
+	resp, err := c.client.CreateOrderItem(ctx, &orderv1.CreateOrderItemRequest{Item: toOrderItem(item)})
+	if err != nil {
+		return 0, rpc.FromError(err)
+	}
+
+	return int(resp.GetId()), nil
+}
+
+func (c *Client) GetOrderItems(ctx context.Context, orderId int) ([]types.OrderItem, error) {
+	list, err := c.client.GetOrderItems(ctx, &orderv1.GetOrderItemsRequest{OrderId: int32(orderId)})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	items := make([]types.OrderItem, len(list.GetItems()))
+	for i, item := range list.GetItems() {
+		items[i] = fromOrderItem(item)
+	}
+
+	return items, nil
+}
+
+func (c *Client) DeleteOrder(ctx context.Context, orderId int) error {
+	_, err := c.client.DeleteOrder(ctx, &orderv1.DeleteOrderRequest{Id: int32(orderId)})
+	if err != nil {
+		return rpc.FromError(err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetOrderById(ctx context.Context, orderId int) (*types.Order, error) {
+	resp, err := c.client.GetOrderById(ctx, &orderv1.GetOrderByIdRequest{Id: int32(orderId)})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	order := fromOrder(resp)
+	return &order, nil
+}
+
+func (c *Client) ListOrders(ctx context.Context) ([]types.Order, error) {
+	list, err := c.client.ListOrders(ctx, &orderv1.ListOrdersRequest{})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromOrderList(list), nil
+}
+
+func (c *Client) UpdateOrder(ctx context.Context, orderId int, order types.Order) error {
+	_, err := c.client.UpdateOrder(ctx, &orderv1.UpdateOrderRequest{Id: int32(orderId), Order: toOrder(order)})
+	if err != nil {
+		return rpc.FromError(err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetOrdersByStatus(ctx context.Context, status string) ([]types.Order, error) {
+	list, err := c.client.GetOrdersByStatus(ctx, &orderv1.GetOrdersByStatusRequest{Status: status})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromOrderList(list), nil
+}
+
+func (c *Client) GetOrdersByUserId(ctx context.Context, userId int) ([]types.Order, error) {
+	list, err := c.client.GetOrdersByUserId(ctx, &orderv1.GetOrdersByUserIdRequest{UserId: int32(userId)})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromOrderList(list), nil
+}
+
+func fromOrderList(list *orderv1.OrderList) []types.Order {
+	orders := make([]types.Order, len(list.GetOrders()))
+	for i, order := range list.GetOrders() {
+		orders[i] = fromOrder(order)
+	}
+
+	return orders
+}
@@ -0,0 +1,199 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/order/types"
+	orderv1 "github.com/4lerman/e_com/proto/order/v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server serves the order store over gRPC.
+type Server struct {
+	orderv1.UnimplementedOrderStoreServer
+	store types.OrderStore
+}
+
+func NewServer(store types.OrderStore) *Server {
+	return &Server{
+		store: store,
+	}
+}
+
+// storeError serves orders that do not exist as NotFound.
+func storeError(err error) error {
+	return rpc.Error(err, types.ErrOrderNotFound)
+}
+
+func (s *Server) CreateOrder(ctx context.Context, req *orderv1.CreateOrderRequest) (*orderv1.CreateOrderResponse, error) {
+	id, err := s.store.CreateOrder(ctx, fromOrder(req.GetOrder()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &orderv1.CreateOrderResponse{Id: int32(id)}, nil
+}
+
+func (s *Server) CreateOrderItem(ctx context.Context, req *orderv1.CreateOrderItemRequest) (*orderv1.CreateOrderItemResponse, error) {
+	id, err := s.store.CreateOrderItem(ctx, fromOrderItem(req.GetItem()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &orderv1.CreateOrderItemResponse{Id: int32(id)}, nil
+}
+
+func (s *Server) GetOrderItems(ctx context.Context, req *orderv1.GetOrderItemsRequest) (*orderv1.OrderItemList, error) {
+	items, err := s.store.GetOrderItems(ctx, int(req.GetOrderId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	list := &orderv1.OrderItemList{Items: make([]*orderv1.OrderItem, len(items))}
+	for i, item := range items {
+		list.Items[i] = toOrderItem(item)
+	}
+
+	return list, nil
+}
+
+func (s *Server) DeleteOrder(ctx context.Context, req *orderv1.DeleteOrderRequest) (*emptypb.Empty, error) {
+	if err := s.store.DeleteOrder(ctx, int(req.GetId())); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetOrderById(ctx context.Context, req *orderv1.GetOrderByIdRequest) (*orderv1.Order, error) {
+	order, err := s.store.GetOrderById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toOrder(*order), nil
+}
+
+func (s *Server) ListOrders(ctx context.Context, req *orderv1.ListOrdersRequest) (*orderv1.OrderList, error) {
+	orders, err := s.store.ListOrders(ctx)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toOrderList(orders), nil
+}
+
+func (s *Server) UpdateOrder(ctx context.Context, req *orderv1.UpdateOrderRequest) (*emptypb.Empty, error) {
+	if err := s.store.UpdateOrder(ctx, int(req.GetId()), fromOrder(req.GetOrder())); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetOrdersByStatus(ctx context.Context, req *orderv1.GetOrdersByStatusRequest) (*orderv1.OrderList, error) {
+	orders, err := s.store.GetOrdersByStatus(ctx, req.GetStatus())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toOrderList(orders), nil
+}
+
+func (s *Server) GetOrdersByUserId(ctx context.Context, req *orderv1.GetOrdersByUserIdRequest) (*orderv1.OrderList, error) {
+	orders, err := s.store.GetOrdersByUserId(ctx, int(req.GetUserId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toOrderList(orders), nil
+}
+
+func toOrder(order types.Order) *orderv1.Order {
+	return &orderv1.Order{
+		Id:              int32(order.ID),
+		UserId:          int32(order.UserID),
+		Total:           order.Total,
+		Status:          string(order.Status),
+		CreatedAt:       timestamppb.New(order.CreatedAt),
+		ShippingAddress: toAddressSnapshot(order.ShippingAddress),
+		BillingAddress:  toAddressSnapshot(order.BillingAddress),
+	}
+}
+
+func fromOrder(order *orderv1.Order) types.Order {
+	return types.Order{
+		ID:              int(order.GetId()),
+		UserID:          int(order.GetUserId()),
+		Total:           order.GetTotal(),
+		Status:          types.OrderStatus(order.GetStatus()),
+		CreatedAt:       order.GetCreatedAt().AsTime(),
+		ShippingAddress: fromAddressSnapshot(order.GetShippingAddress()),
+		BillingAddress:  fromAddressSnapshot(order.GetBillingAddress()),
+	}
+}
+
+func toAddressSnapshot(address *types.AddressSnapshot) *orderv1.AddressSnapshot {
+	if address == nil {
+		return nil
+	}
+
+	return &orderv1.AddressSnapshot{
+		Id:         int32(address.ID),
+		Label:      address.Label,
+		Country:    address.Country,
+		City:       address.City,
+		Street:     address.Street,
+		PostalCode: address.PostalCode,
+		Phone:      address.Phone,
+	}
+}
+
+func fromAddressSnapshot(address *orderv1.AddressSnapshot) *types.AddressSnapshot {
+	if address == nil {
+		return nil
+	}
+
+	return &types.AddressSnapshot{
+		ID:         int(address.GetId()),
+		Label:      address.GetLabel(),
+		Country:    address.GetCountry(),
+		City:       address.GetCity(),
+		Street:     address.GetStreet(),
+		PostalCode: address.GetPostalCode(),
+		Phone:      address.GetPhone(),
+	}
+}
+
+func toOrderList(orders []types.Order) *orderv1.OrderList {
+	list := &orderv1.OrderList{Orders: make([]*orderv1.Order, len(orders))}
+	for i, order := range orders {
+		list.Orders[i] = toOrder(order)
+	}
+
+	return list
+}
+
+func toOrderItem(item types.OrderItem) *orderv1.OrderItem {
+	return &orderv1.OrderItem{
+		Id:        int32(item.ID),
+		OrderId:   int32(item.OrderID),
+		ProductId: int32(item.ProductID),
+		Quantity:  int32(item.Quantity),
+		Price:     item.Price,
+		CreatedAt: timestamppb.New(item.CreatedAt),
+	}
+}
+
+func fromOrderItem(item *orderv1.OrderItem) types.OrderItem {
+	return types.OrderItem{
+		ID:        int(item.GetId()),
+		OrderID:   int(item.GetOrderId()),
+		ProductID: int(item.GetProductId()),
+		Quantity:  int(item.GetQuantity()),
+		Price:     item.GetPrice(),
+		CreatedAt: item.GetCreatedAt().AsTime(),
+	}
+}
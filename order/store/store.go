@@ -1,12 +1,19 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/4lerman/e_com/common/events"
+	"github.com/4lerman/e_com/common/tracing"
 	"github.com/4lerman/e_com/order/types"
 )
 
+const orderColumns = "id, userId, total, status, createdAt, shippingAddress, billingAddress"
+
 type Store struct {
 	db *sql.DB
 }
@@ -17,8 +24,11 @@ func NewStore(db *sql.DB) *Store {
 	}
 }
 
-func (s *Store) ListOrders() ([]types.Order, error) {
-	rows, err := s.db.Query("SELECT * FROM orders")
+func (s *Store) ListOrders(ctx context.Context) ([]types.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderStore.ListOrders")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+orderColumns+" FROM orders")
 
 	if err != nil {
 		return nil, err
@@ -39,20 +49,39 @@ func (s *Store) ListOrders() ([]types.Order, error) {
 	return orders, nil
 }
 
-func (s *Store) CreateOrder(order types.Order) error {
-	_, err := s.db.Exec("INSERT INTO orders (userId, total, status) VALUES ($1, $2, $3)",
-		order.UserID, order.Total, order.Status)
+func (s *Store) CreateOrder(ctx context.Context, order types.Order) (int, error) {
+	ctx, span := tracing.Start(ctx, "OrderStore.CreateOrder")
+	defer span.End()
 
+	shippingId, shipping, err := snapshotValues(order.ShippingAddress)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	billingId, billing, err := snapshotValues(order.BillingAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = s.db.QueryRowContext(ctx, "INSERT INTO orders "+
+		"(userId, total, status, shippingAddressId, shippingAddress, billingAddressId, billingAddress) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		order.UserID, order.Total, order.Status, shippingId, shipping, billingId, billing).Scan(&id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
 
 
-func (s *Store) GetOrderById(orderId int) (*types.Order, error) {
-	rows, err := s.db.Query("SELECT * FROM orders WHERE id = $1", orderId)
+func (s *Store) GetOrderById(ctx context.Context, orderId int) (*types.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderStore.GetOrderById")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+orderColumns+" FROM orders WHERE id = $1", orderId)
 
 	if err != nil {
 		return nil, err
@@ -69,14 +98,17 @@ func (s *Store) GetOrderById(orderId int) (*types.Order, error) {
 	}
 
 	if order.ID == 0 {
-		return nil, fmt.Errorf("order not found")
+		return nil, types.ErrOrderNotFound
 	}
 
 	return order, nil
 }
 
-func (s *Store) GetOrdersByUserId(userId int) ([]types.Order, error) {
-	rows, err := s.db.Query("SELECT * FROM orders WHERE userId = $1", userId)
+func (s *Store) GetOrdersByUserId(ctx context.Context, userId int) ([]types.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderStore.GetOrdersByUserId")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+orderColumns+" FROM orders WHERE userId = $1", userId)
 
 	if err != nil {
 		return nil, err
@@ -98,8 +130,11 @@ func (s *Store) GetOrdersByUserId(userId int) ([]types.Order, error) {
 	return orders, nil
 }
 
-func (s *Store) GetOrdersByStatus(status string) ([]types.Order, error) {
-	rows, err := s.db.Query("SELECT * FROM orders WHERE status = $1", status)
+func (s *Store) GetOrdersByStatus(ctx context.Context, status string) ([]types.Order, error) {
+	ctx, span := tracing.Start(ctx, "OrderStore.GetOrdersByStatus")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+orderColumns+" FROM orders WHERE status = $1", status)
 
 	if err != nil {
 		return nil, err
@@ -121,19 +156,53 @@ func (s *Store) GetOrdersByStatus(status string) ([]types.Order, error) {
 	return orders, nil
 }
 
-func (s *Store) UpdateOrder(orderId int, order types.Order) error {
-	_, err := s.db.Exec("UPDATE orders SET "+
-		"userId = $1, total = $2, status = $3 WHERE id = $4", order.UserID, order.Total, order.Status, orderId)
+// UpdateOrder replaces the order and, if its status changed, publishes an
+// order.status_changed event in the same transaction.
+func (s *Store) UpdateOrder(ctx context.Context, orderId int, order types.Order) error {
+	ctx, span := tracing.Start(ctx, "OrderStore.UpdateOrder")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previous types.OrderStatus
+	err = tx.QueryRowContext(ctx, "SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderId).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	updated, err := scanRowIntoOrder(tx.QueryRowContext(ctx, "UPDATE orders SET "+
+		"userId = $1, total = $2, status = $3 WHERE id = $4 RETURNING "+orderColumns, order.UserID, order.Total, order.Status, orderId))
 
 	if err != nil {
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
+	if updated.Status != previous {
+		change := types.StatusChange{Order: *updated, PreviousStatus: previous}
+		if err := events.Publish(ctx, tx, events.OrderStatusChanged, updated.UserID, orderId, change); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
 	return nil
 }
 
-func (s *Store) DeleteOrder(orderId int) error {
-	_, err := s.db.Exec("DELETE FROM orders WHERE id = $1", orderId)
+func (s *Store) DeleteOrder(ctx context.Context, orderId int) error {
+	ctx, span := tracing.Start(ctx, "OrderStore.DeleteOrder")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM orders WHERE id = $1", orderId)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete order: %w", err)
@@ -143,30 +212,104 @@ func (s *Store) DeleteOrder(orderId int) error {
 }
 
 
-func (s *Store) CreateOrderItem(orderItem types.OrderItem) error {
-	_, err := s.db.Exec("INSERT INTO order_items (orderid, productid, quantity, price) VALUES ($1, $2, $3, $4)",
-		orderItem.OrderID, orderItem.ProductID, orderItem.Quantity, orderItem.Price)
+func (s *Store) CreateOrderItem(ctx context.Context, orderItem types.OrderItem) (int, error) {
+	ctx, span := tracing.Start(ctx, "OrderStore.CreateOrderItem")
+	defer span.End()
+
+	var id int
+	err := s.db.QueryRowContext(ctx, "INSERT INTO order_items (orderid, productid, quantity, price) VALUES ($1, $2, $3, $4) RETURNING id",
+		orderItem.OrderID, orderItem.ProductID, orderItem.Quantity, orderItem.Price).Scan(&id)
 
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return id, nil
 }
 
-func scanRowIntoOrder(rows *sql.Rows) (*types.Order, error) {
+func (s *Store) GetOrderItems(ctx context.Context, orderId int) ([]types.OrderItem, error) {
+	ctx, span := tracing.Start(ctx, "OrderStore.GetOrderItems")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, orderId, productId, quantity, price FROM order_items WHERE orderId = $1 ORDER BY id", orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := []types.OrderItem{}
+	for rows.Next() {
+		var item types.OrderItem
+		err := rows.Scan(
+			&item.ID,
+			&item.OrderID,
+			&item.ProductID,
+			&item.Quantity,
+			&item.Price,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func scanRowIntoOrder(row interface{ Scan(...any) error }) (*types.Order, error) {
 	order := new(types.Order)
+	var shipping, billing []byte
 
-	err := rows.Scan(
+	err := row.Scan(
 		&order.ID,
 		&order.UserID,
 		&order.Total,
 		&order.Status,
 		&order.CreatedAt,
+		&shipping,
+		&billing,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if order.ShippingAddress, err = scanSnapshot(shipping); err != nil {
+		return nil, err
+	}
+	if order.BillingAddress, err = scanSnapshot(billing); err != nil {
+		return nil, err
+	}
+
 	return order, nil
 }
+
+func scanSnapshot(data []byte) (*types.AddressSnapshot, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	address := new(types.AddressSnapshot)
+	if err := json.Unmarshal(data, address); err != nil {
+		return nil, fmt.Errorf("invalid address snapshot: %w", err)
+	}
+
+	return address, nil
+}
+
+// snapshotValues are the address id and JSON columns of a snapshot, both
+// NULL when there is none.
+func snapshotValues(address *types.AddressSnapshot) (sql.NullInt64, sql.NullString, error) {
+	if address == nil {
+		return sql.NullInt64{}, sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(address)
+	if err != nil {
+		return sql.NullInt64{}, sql.NullString{}, err
+	}
+
+	return sql.NullInt64{Int64: int64(address.ID), Valid: true}, sql.NullString{String: string(data), Valid: true}, nil
+}
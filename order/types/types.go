@@ -1,16 +1,32 @@
 package types
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+
+	userTypes "github.com/4lerman/e_com/user/types"
+)
+
+// ErrOrderNotFound is returned for orders that do not exist.
+var ErrOrderNotFound = errors.New("order not found")
 
 type OrderStore interface {
-	CreateOrder(Order) error
-	CreateOrderItem(OrderItem) error
-	DeleteOrder(int) error
-	GetOrderById(int) (*Order, error)
-	ListOrders() ([]Order, error)
-	UpdateOrder(int, Order) error
-	GetOrdersByStatus(string) ([]Order, error)
-	GetOrdersByUserId(int) ([]Order, error)
+	CreateOrder(context.Context, Order) (int, error)
+	CreateOrderItem(context.Context, OrderItem) (int, error)
+	GetOrderItems(context.Context, int) ([]OrderItem, error)
+	DeleteOrder(context.Context, int) error
+	GetOrderById(context.Context, int) (*Order, error)
+	ListOrders(context.Context) ([]Order, error)
+	UpdateOrder(context.Context, int, Order) error
+	GetOrdersByStatus(context.Context, string) ([]Order, error)
+	GetOrdersByUserId(context.Context, int) ([]Order, error)
+}
+
+// UserReader reads the users orders are placed for, and their addresses.
+type UserReader interface {
+	GetUserById(context.Context, int) (*userTypes.User, error)
+	ListAddresses(context.Context, int) ([]userTypes.Address, error)
 }
 
 type OrderStatus string
@@ -22,11 +38,44 @@ const (
 )
 
 type Order struct {
-	ID        int         `json:"id"`
-	UserID    int         `json:"user_id"`
-	Total     float64     `json:"total"`
-	Status    OrderStatus `json:"status"`
-	CreatedAt time.Time   `json:"createdAt"`
+	ID              int              `json:"id"`
+	UserID          int              `json:"user_id"`
+	Total           float64          `json:"total"`
+	Status          OrderStatus      `json:"status"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	ShippingAddress *AddressSnapshot `json:"shipping_address,omitempty"`
+	BillingAddress  *AddressSnapshot `json:"billing_address,omitempty"`
+}
+
+// AddressSnapshot is the copy of a user's address an order keeps, so later
+// changes to the address book leave the order as it was placed. ID is the
+// address it was taken from.
+type AddressSnapshot struct {
+	ID         int    `json:"id"`
+	Label      string `json:"label"`
+	Country    string `json:"country"`
+	City       string `json:"city"`
+	Street     string `json:"street"`
+	PostalCode string `json:"postal_code"`
+	Phone      string `json:"phone"`
+}
+
+func NewAddressSnapshot(address userTypes.Address) *AddressSnapshot {
+	return &AddressSnapshot{
+		ID:         address.ID,
+		Label:      address.Label,
+		Country:    address.Country,
+		City:       address.City,
+		Street:     address.Street,
+		PostalCode: address.PostalCode,
+		Phone:      address.Phone,
+	}
+}
+
+// StatusChange is the data of an order.status_changed event.
+type StatusChange struct {
+	Order
+	PreviousStatus OrderStatus `json:"previous_status"`
 }
 
 type OrderItem struct {
@@ -38,12 +87,17 @@ type OrderItem struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// CreateOrderPayload places an order. The addresses are picked from the
+// user's address book; the defaults are used when they are left out. The
+// order starts out new and empty, its total follows the items added.
 type CreateOrderPayload struct {
-	UserID int         `json:"user_id" validate:"required"`
-	Total  float64     `json:"total" validate:"required"`
-	Status OrderStatus `json:"status" validate:"required"`
+	UserID            int `json:"user_id" validate:"required"`
+	ShippingAddressID int `json:"shipping_address_id" validate:"omitempty"`
+	BillingAddressID  int `json:"billing_address_id" validate:"omitempty"`
 }
 
+// UpdateOrderPayload changes an order; the fields left out keep their
+// values. Clients may not set any of them on their own orders.
 type UpdateOrderPayload struct {
 	UserID int         `json:"user_id" validate:"omitempty"`
 	Total  float64     `json:"total" validate:"omitempty"`
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,33 +11,77 @@ import (
 
 	configs "github.com/4lerman/e_com/common/config"
 	"github.com/4lerman/e_com/common/db"
+	"github.com/4lerman/e_com/common/health"
+	"github.com/4lerman/e_com/common/idempotency"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/metrics"
+	commonRpc "github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/common/tracing"
+	orderRpc "github.com/4lerman/e_com/order/rpc"
 	"github.com/4lerman/e_com/payment/routes"
+	paymentRpc "github.com/4lerman/e_com/payment/rpc"
+	"github.com/4lerman/e_com/payment/service"
 	"github.com/4lerman/e_com/payment/store"
+	paymentv1 "github.com/4lerman/e_com/proto/payment/v1"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"google.golang.org/grpc"
 )
 
 func main() {
+	logger.Init("payments")
+
+	if err := configs.CheckSecret("INTERNAL_SECRET", configs.Envs.Internal_Secret); err != nil {
+		logger.Fatal("invalid configuration", err)
+	}
+
+	shutdownTracing, err := tracing.Init("payments")
+	if err != nil {
+		logger.Fatal("tracing setup failed", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := db.InitStorage()
 	if err != nil {
-		log.Fatal(err)
+		logger.Fatal("db connection failed", err)
 	}
 
-	log.Println("Db connected successfully!")
+	slog.Info("db connected")
+	metrics.RegisterDB(db, "payments")
 
 	paymentStore := store.NewStore(db)
-	paymentHandler := routes.NewHandler(paymentStore)
+
+	idempotencyStore := idempotency.NewStore(db, time.Duration(configs.Envs.Idempotency_Key_Ttl)*time.Second, time.Duration(configs.Envs.Idempotency_Lock_Ttl)*time.Second)
+	go idempotencyStore.PurgeEvery(time.Hour)
+
+	// payments are checked against their orders, which the order service
+	// keeps
+	orderConn, err := commonRpc.Dial(configs.Envs.Orders_Grpc_Addr)
+	if err != nil {
+		logger.Fatal("order service client setup failed", err)
+	}
+	defer orderConn.Close()
+
+	paymentHandler := routes.NewHandler(paymentStore, orderRpc.NewClient(orderConn), idempotencyStore)
 
 	router := mux.NewRouter()
+	router.Use(tracing.Middleware, logger.Middleware, metrics.Middleware, identity.Internal(configs.Envs.Internal_Secret))
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	health.Register(router, map[string]health.Check{
+		"db":       health.DB(db),
+		"provider": service.ProviderConfigured,
+	})
+
 	paymentRouter := router.PathPrefix("/payments").Subrouter()
 	paymentHandler.RegisterRoutes(paymentRouter)
 
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{configs.Envs.Base_Url},
 		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", idempotency.Header},
 		AllowCredentials: true,
-	}).Handler(paymentRouter)
+	}).Handler(router)
 
 	port := configs.Envs.Payments_Port
 	server := &http.Server{
@@ -45,17 +89,26 @@ func main() {
 		Handler: corsHandler,
 	}
 
-	go gracefulShutdown(server)
+	grpcServer := commonRpc.NewServer()
+	paymentv1.RegisterPaymentStoreServer(grpcServer, paymentRpc.NewServer(paymentStore))
 
-	log.Printf("Server(PAYMENTS) is starting on port %s\n", port)
+	go func() {
+		if err := commonRpc.Serve(grpcServer, configs.Envs.Payments_Grpc_Port); err != nil {
+			logger.Fatal("grpc server startup failed", err)
+		}
+	}()
+
+	go gracefulShutdown(server, grpcServer)
+
+	slog.Info("server is starting", "port", port)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server startup failed: %v\n", err)
+		logger.Fatal("server startup failed", err)
 	}
 
-	log.Println("Server(PAYMENTS) gracefully stopped")
+	slog.Info("server gracefully stopped")
 }
 
-func gracefulShutdown(server *http.Server) {
+func gracefulShutdown(server *http.Server, grpcServer *grpc.Server) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
@@ -65,6 +118,8 @@ func gracefulShutdown(server *http.Server) {
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Graceful shutdown failed: %v\n", err)
+		logger.Fatal("graceful shutdown failed", err)
 	}
+
+	grpcServer.GracefulStop()
 }
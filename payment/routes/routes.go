@@ -1,38 +1,59 @@
 package routes
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/4lerman/e_com/common/idempotency"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
 	"github.com/4lerman/e_com/common/utils"
+	orderTypes "github.com/4lerman/e_com/order/types"
 	"github.com/4lerman/e_com/payment/service"
 	"github.com/4lerman/e_com/payment/types"
-	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
-	store types.PaymentStore
+	store       types.PaymentStore
+	orders      types.OrderReader
+	idempotency *idempotency.Store
+	// charge makes the payment through the provider
+	charge func(context.Context) (*types.PaymentResponse, error)
 }
 
-func NewHandler(store types.PaymentStore) *Handler {
+func NewHandler(store types.PaymentStore, orders types.OrderReader, idempotencyStore *idempotency.Store) *Handler {
 	return &Handler{
-		store: store,
+		store:       store,
+		orders:      orders,
+		idempotency: idempotencyStore,
+		charge:      service.MakePayment,
 	}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("", h.handleListPayments).Methods(http.MethodGet)
-	router.HandleFunc("", h.handleCreatePayment).Methods(http.MethodPost)
+	// a retried payment must not charge the customer twice
+	router.Handle("", idempotency.Middleware(h.idempotency, http.HandlerFunc(h.handleCreatePayment))).Methods(http.MethodPost)
 	router.HandleFunc("/search", h.handlePaymentByQuery).Methods(http.MethodGet)
 	router.HandleFunc("/{id}", h.handleGetPaymentById).Methods(http.MethodGet)
 	router.HandleFunc("/{id}", h.handleUpdatePayment).Methods(http.MethodPut)
 	router.HandleFunc("/{id}", h.handleDeletePayment).Methods(http.MethodDelete)
+	router.HandleFunc("/{id}/refund", h.handleRefundPayment).Methods(http.MethodPost)
 }
 
 func (h *Handler) handleListPayments(w http.ResponseWriter, r *http.Request) {
-	payments, err := h.store.ListPayments()
+	var payments []types.Payment
+	var err error
+
+	if caller, restricted := identity.Restricted(r); restricted {
+		payments, err = h.store.GetPaymentsByUserId(r.Context(), caller.UserID)
+	} else {
+		payments, err = h.store.ListPayments(r.Context())
+	}
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -45,28 +66,52 @@ func (h *Handler) handleListPayments(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleCreatePayment(w http.ResponseWriter, r *http.Request) {
 	var payload types.CreatePaymentPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
+	// clients can only pay on their own behalf
+	caller, restricted := identity.Restricted(r)
+	if restricted {
+		payload.UserID = caller.UserID
+	}
+
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	paymentResponse, err := service.MakePayment()
+	// and for their own orders; the orders of others do not exist to them
+	order, err := h.orders.GetOrderById(r.Context(), payload.OrderID)
+	if err != nil || (restricted && order.UserID != caller.UserID) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get order by id: %v", errOrOrderNotFound(err)))
+		return
+	}
+
+	if order.UserID != payload.UserID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("order %d belongs to another user", order.ID))
+		return
+	}
+
+	log := logger.FromContext(r.Context()).With("order_id", payload.OrderID, "user_id", payload.UserID)
+
+	// from here on the card may be charged, so a retry must get this
+	// response back, even a failed one, instead of charging it again
+	idempotency.Keep(w)
+
+	// the status is decided by the provider, never by the client
+	payload.Status = types.Failed
+	paymentResponse, err := h.charge(r.Context())
 	if err != nil {
-		payload.Status = "failed"
-		fmt.Println("Payment failed: ", paymentResponse)
+		log.Warn("payment failed", "error", err)
 	} else {
-		fmt.Println("Payment passed: ", paymentResponse)
-		if paymentResponse.Status == "AUTH"{
-			payload.Status = "success"
+		log.Info("payment processed", "provider_status", paymentResponse.Status, "provider_payment_id", paymentResponse.PaymentID)
+		if paymentResponse.Status == "AUTH" {
+			payload.Status = types.Success
 		}
 	}
 
-	err = h.store.CreatePayment(types.Payment{
+	id, err := h.store.CreatePayment(r.Context(), types.Payment{
 		UserID:  payload.UserID,
 		OrderID: payload.OrderID,
 		Amount:  payload.Amount,
@@ -74,11 +119,14 @@ func (h *Handler) handleCreatePayment(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		log.Error("failed to save processed payment", "status", payload.Status, "error", err)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("payment was processed but could not be saved: %w", err))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, map[string]string{"msg": "Created successfully"})
+	utils.WriteCreated(w, r, fmt.Sprintf("/payments/%d", id), func() (any, error) {
+		return h.store.GetPaymentById(r.Context(), id)
+	})
 
 }
 
@@ -93,9 +141,9 @@ func (h *Handler) handleGetPaymentById(w http.ResponseWriter, r *http.Request) {
 
 	paymentId, _ := strconv.Atoi(id)
 
-	payment, err := h.store.GetPaymentById(paymentId)
-	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get payment by id: %v", err))
+	payment, err := h.store.GetPaymentById(r.Context(), paymentId)
+	if err != nil || !canAccess(r, payment) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get payment by id: %v", errOrNotFound(err)))
 		return
 	}
 
@@ -115,17 +163,16 @@ func (h *Handler) handleUpdatePayment(w http.ResponseWriter, r *http.Request) {
 
 	var payload types.UpdatePaymentPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	err := h.store.UpdatePayment(paymentId, types.Payment{
+	err := h.store.UpdatePayment(r.Context(), paymentId, types.Payment{
 		UserID:  payload.UserID,
 		OrderID: payload.OrderID,
 		Amount:  payload.Amount,
@@ -150,7 +197,7 @@ func (h *Handler) handleDeletePayment(w http.ResponseWriter, r *http.Request) {
 
 	paymentId, _ := strconv.Atoi(id)
 
-	if err := h.store.DeletePayment(paymentId); err != nil {
+	if err := h.store.DeletePayment(r.Context(), paymentId); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -158,6 +205,42 @@ func (h *Handler) handleDeletePayment(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Deleted successfully"})
 }
 
+func (h *Handler) handleRefundPayment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if id == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("id is not indicated"))
+		return
+	}
+
+	paymentId, _ := strconv.Atoi(id)
+
+	// customers cannot refund themselves; admins and staff holding
+	// payment:refund can
+	if _, restricted := identity.Restricted(r); restricted {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("payments are refunded by admins and staff only"))
+		return
+	}
+
+	payment, err := h.store.RefundPayment(r.Context(), paymentId)
+	switch {
+	case errors.Is(err, types.ErrPaymentNotFound):
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	case errors.Is(err, types.ErrNotRefundable):
+		utils.WriteError(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("payment refunded", "payment_id", payment.ID, "order_id", payment.OrderID, "amount", payment.Amount)
+
+	utils.WriteJSON(w, http.StatusOK, payment)
+}
+
 func (h *Handler) handlePaymentByQuery(w http.ResponseWriter, r *http.Request) {
 	status := r.URL.Query().Get("status")
 	user := r.URL.Query().Get("user")
@@ -170,11 +253,11 @@ func (h *Handler) handlePaymentByQuery(w http.ResponseWriter, r *http.Request) {
 	orderId, _ := strconv.Atoi(order)
 
 	if status != "" {
-		payments, err = h.store.GetPaymentsByStatus(status)
+		payments, err = h.store.GetPaymentsByStatus(r.Context(), status)
 	} else if user != "" {
-		payments, err = h.store.GetPaymentsByUserId(userId)
+		payments, err = h.store.GetPaymentsByUserId(r.Context(), userId)
 	} else if order != "" {
-		payments, err = h.store.GetPaymentsByOrderId(orderId)
+		payments, err = h.store.GetPaymentsByOrderId(r.Context(), orderId)
 	} else {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("either name or email query parameter is required"))
 		return
@@ -185,5 +268,38 @@ func (h *Handler) handlePaymentByQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, restricted := identity.Restricted(r); restricted {
+		own := []types.Payment{}
+		for _, payment := range payments {
+			if canAccess(r, &payment) {
+				own = append(own, payment)
+			}
+		}
+		payments = own
+	}
+
 	utils.WriteJSON(w, http.StatusOK, payments)
 }
+
+// canAccess reports whether the caller may see the payment: admins and
+// internal calls see every payment, clients only their own.
+func canAccess(r *http.Request, payment *types.Payment) bool {
+	caller, restricted := identity.Restricted(r)
+	return !restricted || payment.UserID == caller.UserID
+}
+
+func errOrNotFound(err error) error {
+	if err != nil {
+		return err
+	}
+
+	return types.ErrPaymentNotFound
+}
+
+func errOrOrderNotFound(err error) error {
+	if err != nil {
+		return err
+	}
+
+	return orderTypes.ErrOrderNotFound
+}
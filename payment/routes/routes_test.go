@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/4lerman/e_com/common/db/dbtest"
+	"github.com/4lerman/e_com/common/idempotency"
+	"github.com/4lerman/e_com/common/identity"
+	orderTypes "github.com/4lerman/e_com/order/types"
+	"github.com/4lerman/e_com/payment/types"
+	"github.com/gorilla/mux"
+)
+
+// failingStore fails to save every payment.
+type failingStore struct {
+	types.PaymentStore
+}
+
+func (failingStore) CreatePayment(context.Context, types.Payment) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+type orderReader struct {
+	order orderTypes.Order
+}
+
+func (o orderReader) GetOrderById(context.Context, int) (*orderTypes.Order, error) {
+	return &o.order, nil
+}
+
+func TestCreatePaymentKeepsKeyAfterCharge(t *testing.T) {
+	store := idempotency.NewStore(dbtest.Open(t), time.Hour, time.Minute)
+	h := NewHandler(failingStore{}, orderReader{orderTypes.Order{ID: 3, UserID: 7}}, store)
+
+	charges := 0
+	h.charge = func(context.Context) (*types.PaymentResponse, error) {
+		charges++
+		return &types.PaymentResponse{Status: "AUTH", PaymentID: fmt.Sprint(charges)}, nil
+	}
+
+	router := mux.NewRouter()
+	h.RegisterRoutes(router.PathPrefix("/payments").Subrouter())
+
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	send := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{"order_id":3,"amount":100}`))
+		r.Header.Set(idempotency.Header, key)
+		identity.Set(r, identity.Identity{UserID: 7, Role: identity.Client})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	first := send()
+	if first.Code != http.StatusInternalServerError {
+		t.Fatalf("first request status = %d, want %d", first.Code, http.StatusInternalServerError)
+	}
+
+	retry := send()
+	if retry.Code != http.StatusInternalServerError {
+		t.Errorf("retry status = %d, want %d", retry.Code, http.StatusInternalServerError)
+	}
+	if retry.Header().Get(idempotency.ReplayedHeader) != "true" {
+		t.Errorf("retry was not replayed")
+	}
+	if charges != 1 {
+		t.Errorf("card charged %d times, want 1", charges)
+	}
+}
+
+// refundStore refunds the successful payments it holds.
+type refundStore struct {
+	types.PaymentStore
+	payments map[int]types.Payment
+}
+
+func (s refundStore) RefundPayment(_ context.Context, id int) (*types.Payment, error) {
+	payment, ok := s.payments[id]
+	switch {
+	case !ok:
+		return nil, types.ErrPaymentNotFound
+	case payment.Status != types.Success:
+		return nil, types.ErrNotRefundable
+	}
+
+	payment.Status = types.Refunded
+	s.payments[id] = payment
+	return &payment, nil
+}
+
+func TestRefundPayment(t *testing.T) {
+	client := identity.Identity{UserID: 7, Role: identity.Client}
+	finance := identity.Identity{UserID: 2, Role: "finance", Permission: "payment:refund"}
+
+	tests := []struct {
+		name   string
+		caller identity.Identity
+		id     int
+		want   int
+	}{
+		{"client refunds their own payment", client, 1, http.StatusForbidden},
+		{"staff refunds a payment", finance, 1, http.StatusOK},
+		{"staff refunds a failed payment", finance, 2, http.StatusConflict},
+		{"staff refunds a missing payment", finance, 3, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := refundStore{payments: map[int]types.Payment{
+				1: {ID: 1, UserID: 7, OrderID: 3, Amount: 100, Status: types.Success},
+				2: {ID: 2, UserID: 7, OrderID: 3, Amount: 100, Status: types.Failed},
+			}}
+			h := NewHandler(store, nil, nil)
+
+			router := mux.NewRouter()
+			h.RegisterRoutes(router.PathPrefix("/payments").Subrouter())
+
+			r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/payments/%d/refund", tt.id), nil)
+			identity.Set(r, tt.caller)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusOK && store.payments[tt.id].Status != types.Refunded {
+				t.Errorf("payment status = %s, want %s", store.payments[tt.id].Status, types.Refunded)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/payment/types"
+	paymentv1 "github.com/4lerman/e_com/proto/payment/v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server serves the payment store over gRPC.
+type Server struct {
+	paymentv1.UnimplementedPaymentStoreServer
+	store types.PaymentStore
+}
+
+func NewServer(store types.PaymentStore) *Server {
+	return &Server{
+		store: store,
+	}
+}
+
+// storeError serves payments that do not exist as NotFound.
+func storeError(err error) error {
+	return rpc.Error(err, types.ErrPaymentNotFound)
+}
+
+func (s *Server) CreatePayment(ctx context.Context, req *paymentv1.CreatePaymentRequest) (*paymentv1.CreatePaymentResponse, error) {
+	id, err := s.store.CreatePayment(ctx, fromPayment(req.GetPayment()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &paymentv1.CreatePaymentResponse{Id: int32(id)}, nil
+}
+
+func (s *Server) DeletePayment(ctx context.Context, req *paymentv1.DeletePaymentRequest) (*emptypb.Empty, error) {
+	if err := s.store.DeletePayment(ctx, int(req.GetId())); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetPaymentById(ctx context.Context, req *paymentv1.GetPaymentByIdRequest) (*paymentv1.Payment, error) {
+	payment, err := s.store.GetPaymentById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toPayment(*payment), nil
+}
+
+func (s *Server) ListPayments(ctx context.Context, req *paymentv1.ListPaymentsRequest) (*paymentv1.PaymentList, error) {
+	payments, err := s.store.ListPayments(ctx)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toPaymentList(payments), nil
+}
+
+func (s *Server) UpdatePayment(ctx context.Context, req *paymentv1.UpdatePaymentRequest) (*emptypb.Empty, error) {
+	if err := s.store.UpdatePayment(ctx, int(req.GetId()), fromPayment(req.GetPayment())); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetPaymentsByStatus(ctx context.Context, req *paymentv1.GetPaymentsByStatusRequest) (*paymentv1.PaymentList, error) {
+	payments, err := s.store.GetPaymentsByStatus(ctx, req.GetStatus())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toPaymentList(payments), nil
+}
+
+func (s *Server) GetPaymentsByUserId(ctx context.Context, req *paymentv1.GetPaymentsByUserIdRequest) (*paymentv1.PaymentList, error) {
+	payments, err := s.store.GetPaymentsByUserId(ctx, int(req.GetUserId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toPaymentList(payments), nil
+}
+
+func (s *Server) GetPaymentsByOrderId(ctx context.Context, req *paymentv1.GetPaymentsByOrderIdRequest) (*paymentv1.PaymentList, error) {
+	payments, err := s.store.GetPaymentsByOrderId(ctx, int(req.GetOrderId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toPaymentList(payments), nil
+}
+
+func toPayment(payment types.Payment) *paymentv1.Payment {
+	return &paymentv1.Payment{
+		Id:          int32(payment.ID),
+		UserId:      int32(payment.UserID),
+		OrderId:     int32(payment.OrderID),
+		Amount:      payment.Amount,
+		PaymentDate: timestamppb.New(payment.PaymentDate),
+		Status:      string(payment.Status),
+	}
+}
+
+func fromPayment(payment *paymentv1.Payment) types.Payment {
+	return types.Payment{
+		ID:          int(payment.GetId()),
+		UserID:      int(payment.GetUserId()),
+		OrderID:     int(payment.GetOrderId()),
+		Amount:      payment.GetAmount(),
+		PaymentDate: payment.GetPaymentDate().AsTime(),
+		Status:      types.PaymentStatus(payment.GetStatus()),
+	}
+}
+
+func toPaymentList(payments []types.Payment) *paymentv1.PaymentList {
+	list := &paymentv1.PaymentList{Payments: make([]*paymentv1.Payment, len(payments))}
+	for i, payment := range payments {
+		list.Payments[i] = toPayment(payment)
+	}
+
+	return list
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"github.com/4lerman/e_com/payment/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	providerCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payment_provider_calls_total",
+		Help: "HTTP calls to the payment provider, by call (token, public_key, cryptopay) and result (ok, error).",
+	}, []string{"call", "result"})
+
+	providerOutcomes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payment_provider_outcomes_total",
+		Help: "Payments attempted through the provider, by outcome (authorized, declined, error).",
+	}, []string{"outcome"})
+
+	providerDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "payment_provider_duration_seconds",
+		Help:    "Time to make a payment through the provider, all calls included.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func countCall(call string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	providerCalls.WithLabelValues(call, result).Inc()
+}
+
+func outcome(payment *types.PaymentResponse, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case payment.Status == "AUTH":
+		return "authorized"
+	default:
+		return "declined"
+	}
+}
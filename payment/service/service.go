@@ -2,6 +2,7 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -13,13 +14,46 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"time"
 
 	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/tracing"
 	"github.com/4lerman/e_com/common/utils"
 	"github.com/4lerman/e_com/payment/types"
 )
 
-func GetPaymentToken() (*types.TokenResponse, error) {
+// providerClient traces every call to the provider.
+var providerClient = &http.Client{Transport: tracing.Transport(http.DefaultTransport)}
+
+// send makes one provider call in its own span and counts it.
+func send(req *http.Request, call string) (*http.Response, error) {
+	ctx, span := tracing.Start(req.Context(), "provider."+call)
+
+	resp, err := providerClient.Do(req.WithContext(ctx))
+	tracing.End(span, err)
+	countCall(call, err)
+
+	return resp, err
+}
+
+// ProviderConfigured checks the payment provider URLs are set, so payments
+// can be made at all.
+func ProviderConfigured(ctx context.Context) error {
+	for name, raw := range map[string]string{
+		"TOKEN_URL":        configs.Envs.Token_Url,
+		"MAKE_PAYMENT_URL": configs.Envs.Make_Payment_Url,
+	} {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s is not a valid url: %q", name, raw)
+		}
+	}
+
+	return nil
+}
+
+func GetPaymentToken(ctx context.Context) (*types.TokenResponse, error) {
 	tokenUrl := configs.Envs.Token_Url
 
 	body := &bytes.Buffer{}
@@ -39,15 +73,14 @@ func GetPaymentToken() (*types.TokenResponse, error) {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, tokenUrl, body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenUrl, body)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := send(req, "token")
 	if err != nil {
 		return nil, err
 	}
@@ -74,9 +107,14 @@ func GetPaymentToken() (*types.TokenResponse, error) {
 	return &token, nil
 }
 
-func GetPublicKey() (*rsa.PublicKey, error) {
+func GetPublicKey(ctx context.Context) (*rsa.PublicKey, error) {
 	publicKeyURL := "https://testepay.homebank.kz/api/public.rsa"
-	resp, err := http.Get(publicKeyURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, publicKeyURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := send(req, "public_key")
 	if err != nil {
 		return nil, err
 	}
@@ -101,8 +139,8 @@ func GetPublicKey() (*rsa.PublicKey, error) {
 	return rsaPublicKey, nil
 }
 
-func encryptData() (string, error) {
-	publicKey, err := GetPublicKey()
+func encryptData(ctx context.Context) (string, error) {
+	publicKey, err := GetPublicKey(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -123,16 +161,28 @@ func encryptData() (string, error) {
 	return base64.StdEncoding.EncodeToString(encryptedData), nil
 }
 
-func MakePayment() (*types.PaymentResponse, error) {
-	paymentUrl := configs.Envs.Make_Payment_Url
-	paymentToken, err := GetPaymentToken()
-	fmt.Println("Payment token", paymentToken.AccessToken)
+// MakePayment charges the card through the provider and records the
+// outcome in the provider metrics.
+func MakePayment(ctx context.Context) (*types.PaymentResponse, error) {
+	ctx, span := tracing.Start(ctx, "provider.MakePayment")
 
+	start := time.Now()
+	payment, err := makePayment(ctx)
+	tracing.End(span, err)
+	providerDuration.Observe(time.Since(start).Seconds())
+	providerOutcomes.WithLabelValues(outcome(payment, err)).Inc()
+
+	return payment, err
+}
+
+func makePayment(ctx context.Context) (*types.PaymentResponse, error) {
+	paymentUrl := configs.Envs.Make_Payment_Url
+	paymentToken, err := GetPaymentToken(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get payment token: %v", err)
 	}
 
-	encryptedData, err := encryptData()
+	encryptedData, err := encryptData(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error when encrypting key: %v", err)
 	}
@@ -156,15 +206,14 @@ func MakePayment() (*types.PaymentResponse, error) {
 
 	jsonBody, _ := json.Marshal(body)
 
-	req, err := http.NewRequest(http.MethodPost, paymentUrl, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, paymentUrl, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+paymentToken.AccessToken)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := send(req, "cryptopay")
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform request: %v", err)
 	}
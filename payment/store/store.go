@@ -1,9 +1,13 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
+	"github.com/4lerman/e_com/common/events"
+	"github.com/4lerman/e_com/common/tracing"
 	"github.com/4lerman/e_com/payment/types"
 )
 
@@ -17,8 +21,11 @@ func NewStore(db *sql.DB) *Store {
 	}
 }
 
-func (s *Store) ListPayments() ([]types.Payment, error) {
-	rows, err := s.db.Query("SELECT * FROM payments")
+func (s *Store) ListPayments(ctx context.Context) ([]types.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.ListPayments")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM payments")
 
 	if err != nil {
 		return nil, err
@@ -39,19 +46,42 @@ func (s *Store) ListPayments() ([]types.Payment, error) {
 	return payments, nil
 }
 
-func (s *Store) CreatePayment(payment types.Payment) error {
-	_, err := s.db.Exec("INSERT INTO payments (userId, orderId, amount, status)"+
-		"VALUES ($1, $2, $3, $4)", payment.UserID, payment.OrderID, payment.Amount, payment.Status)
+// CreatePayment records the payment and publishes a payment.created event
+// in the same transaction.
+func (s *Store) CreatePayment(ctx context.Context, payment types.Payment) (int, error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.CreatePayment")
+	defer span.End()
 
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	return nil
+	err = tx.QueryRowContext(ctx, "INSERT INTO payments (userId, orderId, amount, status)"+
+		"VALUES ($1, $2, $3, $4) RETURNING id, paymentDate", payment.UserID, payment.OrderID, payment.Amount, payment.Status).
+		Scan(&payment.ID, &payment.PaymentDate)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := events.Publish(ctx, tx, events.PaymentCreated, payment.UserID, payment.OrderID, payment); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return payment.ID, nil
 }
 
-func (s *Store) GetPaymentById(paymentId int) (*types.Payment, error) {
-	rows, err := s.db.Query("SELECT * FROM payments WHERE id = $1", paymentId)
+func (s *Store) GetPaymentById(ctx context.Context, paymentId int) (*types.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.GetPaymentById")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM payments WHERE id = $1", paymentId)
 
 	if err != nil {
 		return nil, err
@@ -68,14 +98,17 @@ func (s *Store) GetPaymentById(paymentId int) (*types.Payment, error) {
 	}
 
 	if payment.ID == 0 {
-		return nil, fmt.Errorf("payment not found")
+		return nil, types.ErrPaymentNotFound
 	}
 
 	return payment, nil
 }
 
-func (s *Store) GetPaymentsByStatus(status string) ([]types.Payment, error) {
-	rows, err := s.db.Query("SELECT * FROM payments WHERE status = $1", status)
+func (s *Store) GetPaymentsByStatus(ctx context.Context, status string) ([]types.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.GetPaymentsByStatus")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM payments WHERE status = $1", status)
 
 	if err != nil {
 		return nil, err
@@ -94,8 +127,11 @@ func (s *Store) GetPaymentsByStatus(status string) ([]types.Payment, error) {
 	return payments, nil
 }
 
-func (s *Store) GetPaymentsByUserId(userId int) ([]types.Payment, error) {
-	rows, err := s.db.Query("SELECT * FROM payments WHERE userId = $1", userId)
+func (s *Store) GetPaymentsByUserId(ctx context.Context, userId int) ([]types.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.GetPaymentsByUserId")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM payments WHERE userId = $1", userId)
 
 	if err != nil {
 		return nil, err
@@ -114,8 +150,11 @@ func (s *Store) GetPaymentsByUserId(userId int) ([]types.Payment, error) {
 	return payments, nil
 }
 
-func (s *Store) GetPaymentsByOrderId(orderId int) ([]types.Payment, error) {
-	rows, err := s.db.Query("SELECT * FROM payments WHERE orderId = $1", orderId)
+func (s *Store) GetPaymentsByOrderId(ctx context.Context, orderId int) ([]types.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.GetPaymentsByOrderId")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM payments WHERE orderId = $1", orderId)
 
 	if err != nil {
 		return nil, err
@@ -135,19 +174,88 @@ func (s *Store) GetPaymentsByOrderId(orderId int) ([]types.Payment, error) {
 }
 
 
-func (s *Store) UpdatePayment(paymentId int, payment types.Payment) error {
-	_, err := s.db.Exec("UPDATE payments SET "+
-		"userId = $1, orderId = $2, amount = $3 WHERE id = $4", payment.UserID, payment.OrderID, payment.Amount, paymentId)
+// UpdatePayment changes the payment and publishes a payment.updated event
+// in the same transaction.
+func (s *Store) UpdatePayment(ctx context.Context, paymentId int, payment types.Payment) error {
+	ctx, span := tracing.Start(ctx, "PaymentStore.UpdatePayment")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated := types.Payment{ID: paymentId}
+	err = tx.QueryRowContext(ctx, "UPDATE payments SET "+
+		"userId = $1, orderId = $2, amount = $3 WHERE id = $4 RETURNING userId, orderId, amount, paymentDate, status", payment.UserID, payment.OrderID, payment.Amount, paymentId).
+		Scan(&updated.UserID, &updated.OrderID, &updated.Amount, &updated.PaymentDate, &updated.Status)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update payment: %w", err)
 	}
 
+	if err := events.Publish(ctx, tx, events.PaymentUpdated, updated.UserID, updated.OrderID, updated); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
 	return nil
 }
 
-func (s *Store) DeletePayment(paymentId int) error {
-	_, err := s.db.Exec("DELETE FROM payments WHERE id = $1", paymentId)
+// RefundPayment marks a successful payment refunded and publishes a
+// payment.updated event in the same transaction.
+func (s *Store) RefundPayment(ctx context.Context, paymentId int) (*types.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.RefundPayment")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status types.PaymentStatus
+	err = tx.QueryRowContext(ctx, "SELECT status FROM payments WHERE id = $1 FOR UPDATE", paymentId).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, types.ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+	if status != types.Success {
+		return nil, types.ErrNotRefundable
+	}
+
+	refunded := types.Payment{ID: paymentId}
+	err = tx.QueryRowContext(ctx, "UPDATE payments SET status = $1 WHERE id = $2 RETURNING userId, orderId, amount, paymentDate, status", types.Refunded, paymentId).
+		Scan(&refunded.UserID, &refunded.OrderID, &refunded.Amount, &refunded.PaymentDate, &refunded.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	if err := events.Publish(ctx, tx, events.PaymentUpdated, refunded.UserID, refunded.OrderID, refunded); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+
+	return &refunded, nil
+}
+
+func (s *Store) DeletePayment(ctx context.Context, paymentId int) error {
+	ctx, span := tracing.Start(ctx, "PaymentStore.DeletePayment")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM payments WHERE id = $1", paymentId)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete payment: %w", err)
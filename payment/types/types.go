@@ -1,25 +1,43 @@
 package types
 
 import (
+	"context"
+	"errors"
 	"time"
+
+	orderTypes "github.com/4lerman/e_com/order/types"
 )
 
+// ErrPaymentNotFound is returned for payments that do not exist.
+var ErrPaymentNotFound = errors.New("payment not found")
+
+// ErrNotRefundable is returned for refunds of payments that did not succeed
+// or were refunded already.
+var ErrNotRefundable = errors.New("only successful payments can be refunded")
+
 type PaymentStore interface {
-	CreatePayment(Payment) error
-	DeletePayment(int) error
-	GetPaymentById(int) (*Payment, error)
-	ListPayments() ([]Payment, error)
-	UpdatePayment(int, Payment) error
-	GetPaymentsByStatus(string) ([]Payment, error)
-	GetPaymentsByUserId(int) ([]Payment, error)
-	GetPaymentsByOrderId(int) ([]Payment, error)
+	CreatePayment(context.Context, Payment) (int, error)
+	DeletePayment(context.Context, int) error
+	GetPaymentById(context.Context, int) (*Payment, error)
+	ListPayments(context.Context) ([]Payment, error)
+	UpdatePayment(context.Context, int, Payment) error
+	RefundPayment(context.Context, int) (*Payment, error)
+	GetPaymentsByStatus(context.Context, string) ([]Payment, error)
+	GetPaymentsByUserId(context.Context, int) ([]Payment, error)
+	GetPaymentsByOrderId(context.Context, int) ([]Payment, error)
+}
+
+// OrderReader reads the orders payments are made for.
+type OrderReader interface {
+	GetOrderById(context.Context, int) (*orderTypes.Order, error)
 }
 
 type PaymentStatus string
 
 const (
-	Success PaymentStatus = "success"
-	Failed  PaymentStatus = "failed"
+	Success  PaymentStatus = "success"
+	Failed   PaymentStatus = "failed"
+	Refunded PaymentStatus = "refunded"
 )
 
 type Payment struct {
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,51 +11,88 @@ import (
 
 	configs "github.com/4lerman/e_com/common/config"
 	"github.com/4lerman/e_com/common/db"
+	"github.com/4lerman/e_com/common/health"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/metrics"
+	commonRpc "github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/common/tracing"
 	"github.com/4lerman/e_com/product/routes"
+	productRpc "github.com/4lerman/e_com/product/rpc"
 	"github.com/4lerman/e_com/product/store"
+	productv1 "github.com/4lerman/e_com/proto/product/v1"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"google.golang.org/grpc"
 )
 
 func main() {
+	logger.Init("products")
+
+	if err := configs.CheckSecret("INTERNAL_SECRET", configs.Envs.Internal_Secret); err != nil {
+		logger.Fatal("invalid configuration", err)
+	}
+
+	shutdownTracing, err := tracing.Init("products")
+	if err != nil {
+		logger.Fatal("tracing setup failed", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := db.InitStorage()
 	if err != nil {
-		log.Fatal(err)
+		logger.Fatal("db connection failed", err)
 	}
 
-	log.Println("Db connected successfully!")
+	slog.Info("db connected")
+	metrics.RegisterDB(db, "products")
 
 	productStore := store.NewStore(db)
 	productHandler := routes.NewHandler(productStore)
-	
+
 	router := mux.NewRouter()
+	router.Use(tracing.Middleware, logger.Middleware, metrics.Middleware, identity.Internal(configs.Envs.Internal_Secret))
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	health.Register(router, map[string]health.Check{
+		"db": health.DB(db),
+	})
+
 	productRouter := router.PathPrefix("/products").Subrouter()
 	productHandler.RegisterRoutes(productRouter)
 
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{configs.Envs.Base_Url},		
+		AllowedOrigins:   []string{configs.Envs.Base_Url},
 		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
 		AllowedHeaders:   []string{"Content-Type"},
 		AllowCredentials: true,
-	}).Handler(productRouter)
+	}).Handler(router)
 
 	port := configs.Envs.Products_Port
 	server := &http.Server{
-		Addr:   ":" + port,
+		Addr:    ":" + port,
 		Handler: corsHandler,
 	}
 
-	go gracefulShutdown(server)
+	grpcServer := commonRpc.NewServer()
+	productv1.RegisterProductStoreServer(grpcServer, productRpc.NewServer(productStore))
+
+	go func() {
+		if err := commonRpc.Serve(grpcServer, configs.Envs.Products_Grpc_Port); err != nil {
+			logger.Fatal("grpc server startup failed", err)
+		}
+	}()
 
-	log.Printf("Server(Products) is starting on port %s\n", port)
+	go gracefulShutdown(server, grpcServer)
+
+	slog.Info("server is starting", "port", port)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server startup failed: %v\n", err)
+		logger.Fatal("server startup failed", err)
 	}
 
-	log.Println("Server(Products) gracefully stopped")
+	slog.Info("server gracefully stopped")
 }
 
-func gracefulShutdown(server *http.Server) {
+func gracefulShutdown(server *http.Server, grpcServer *grpc.Server) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
@@ -65,6 +102,8 @@ func gracefulShutdown(server *http.Server) {
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Graceful shutdown failed: %v\n", err)
+		logger.Fatal("graceful shutdown failed", err)
 	}
-}
\ No newline at end of file
+
+	grpcServer.GracefulStop()
+}
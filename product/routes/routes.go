@@ -4,10 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/4lerman/e_com/common/utils"
 	"github.com/4lerman/e_com/product/types"
-	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
 
@@ -33,17 +34,16 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 func (h *Handler) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 	var payload types.CreateProductPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload %v", errors))
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	err := h.store.CreateProduct(types.Product{
+	id, err := h.store.CreateProduct(r.Context(), types.Product{
 		Name:        payload.Name,
 		Description: payload.Description,
 		Price:       payload.Price,
@@ -55,17 +55,41 @@ func (h *Handler) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+
+	utils.WriteCreated(w, r, fmt.Sprintf("/products/%d", id), func() (any, error) {
+		return h.store.GetProductByID(r.Context(), id)
+	})
 }
 
 func (h *Handler) handleGetProducts(w http.ResponseWriter, r *http.Request) {
-	ps, err := h.store.GetProducts()
+	var ps []types.Product
+	var err error
+
+	// ?ids=1,2,3 fetches several products in one call
+	if raw := r.URL.Query().Get("ids"); raw != "" {
+		var ids []int
+		for _, part := range strings.Split(raw, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid product id %q", part))
+				return
+			}
+			ids = append(ids, id)
+		}
+
+		ps, err = h.store.GetProductsByIDs(r.Context(), ids)
+	} else {
+		ps, err = h.store.GetProducts(r.Context())
+	}
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, ps)
+	// lists carry no Last-Modified: deleting a product leaves the others'
+	// update times as they were, so only the ETag notices
+	utils.WriteJSONConditional(w, r, ps, time.Time{})
 }
 
 func (h *Handler) handleGetProductById(w http.ResponseWriter, r *http.Request) {
@@ -79,13 +103,13 @@ func (h *Handler) handleGetProductById(w http.ResponseWriter, r *http.Request) {
 
 	productId, _ := strconv.Atoi(id)
 
-	product, err := h.store.GetProductByID(productId)
+	product, err := h.store.GetProductByID(r.Context(), productId)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get product by id: %v", err))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, product)
+	utils.WriteJSONConditional(w, r, product, product.UpdatedAt)
 }
 
 func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
@@ -101,17 +125,16 @@ func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	var payload types.UpdateProductPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	err := h.store.UpdateProduct(product, types.Product{
+	err := h.store.UpdateProduct(r.Context(), product, types.Product{
 		Name:        payload.Name,
 		Description: payload.Description,
 		Price:       payload.Price,
@@ -138,7 +161,7 @@ func (h *Handler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	productId, _ := strconv.Atoi(id)
 
-	if err := h.store.DeleteProduct(productId); err != nil {
+	if err := h.store.DeleteProduct(r.Context(), productId); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -154,9 +177,9 @@ func (h *Handler) handleProductByNameOrCategory(w http.ResponseWriter, r *http.R
 	var err error
 
 	if name != "" {
-		products, err = h.store.GetProductsByName(name)
+		products, err = h.store.GetProductsByName(r.Context(), name)
 	} else if email != "" {
-		products, err = h.store.GetProductsByCategory(email)
+		products, err = h.store.GetProductsByCategory(r.Context(), email)
 	} else {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("either name or category query parameter is required"))
 		return
@@ -167,5 +190,5 @@ func (h *Handler) handleProductByNameOrCategory(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, products)
+	utils.WriteJSONConditional(w, r, products, time.Time{})
 }
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/product/types"
+	productv1 "github.com/4lerman/e_com/proto/product/v1"
+	"google.golang.org/grpc"
+)
+
+// Client is a types.ProductStore backed by the product service's gRPC API,
+// for services that need products without sharing its database.
+type Client struct {
+	client productv1.ProductStoreClient
+}
+
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{
+		client: productv1.NewProductStoreClient(conn),
+	}
+}
+
+func (c *Client) GetProducts(ctx context.Context) ([]types.Product, error) {
+	list, err := c.client.GetProducts(ctx, &productv1.GetProductsRequest{})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromProductList(list), nil
+}
+
+func (c *Client) CreateProduct(ctx context.Context, product types.Product) (int, error) {
+	resp, err := c.client.CreateProduct(ctx, &productv1.CreateProductRequest{Product: toProduct(product)})
+	if err != nil {
+		return 0, rpc.FromError(err)
+	}
+
+	return int(resp.GetId()), nil
+}
+
+func (c *Client) GetProductByID(ctx context.Context, productId int) (*types.Product, error) {
+	resp, err := c.client.GetProductByID(ctx, &productv1.GetProductByIDRequest{Id: int32(productId)})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	product := fromProduct(resp)
+	return &product, nil
+}
+
+func (c *Client) GetProductsByIDs(ctx context.Context, productIds []int) ([]types.Product, error) {
+	ids := make([]int32, len(productIds))
+	for i, id := range productIds {
+		ids[i] = int32(id)
+	}
+
+	list, err := c.client.GetProductsByIDs(ctx, &productv1.GetProductsByIDsRequest{Ids: ids})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromProductList(list), nil
+}
+
+func (c *Client) UpdateProduct(ctx context.Context, productId int, product types.Product) error {
+	_, err := c.client.UpdateProduct(ctx, &productv1.UpdateProductRequest{Id: int32(productId), Product: toProduct(product)})
+	if err != nil {
+		return rpc.FromError(err)
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteProduct(ctx context.Context, productId int) error {
+	_, err := c.client.DeleteProduct(ctx, &productv1.DeleteProductRequest{Id: int32(productId)})
+	if err != nil {
+		return rpc.FromError(err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetProductsByName(ctx context.Context, name string) ([]types.Product, error) {
+	list, err := c.client.GetProductsByName(ctx, &productv1.GetProductsByNameRequest{Name: name})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromProductList(list), nil
+}
+
+func (c *Client) GetProductsByCategory(ctx context.Context, category string) ([]types.Product, error) {
+	list, err := c.client.GetProductsByCategory(ctx, &productv1.GetProductsByCategoryRequest{Category: category})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromProductList(list), nil
+}
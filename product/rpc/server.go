@@ -0,0 +1,147 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/product/types"
+	productv1 "github.com/4lerman/e_com/proto/product/v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server serves the product store over gRPC.
+type Server struct {
+	productv1.UnimplementedProductStoreServer
+	store types.ProductStore
+}
+
+func NewServer(store types.ProductStore) *Server {
+	return &Server{
+		store: store,
+	}
+}
+
+// storeError serves products that do not exist as NotFound.
+func storeError(err error) error {
+	return rpc.Error(err, types.ErrProductNotFound)
+}
+
+func (s *Server) GetProducts(ctx context.Context, req *productv1.GetProductsRequest) (*productv1.ProductList, error) {
+	products, err := s.store.GetProducts(ctx)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toProductList(products), nil
+}
+
+func (s *Server) CreateProduct(ctx context.Context, req *productv1.CreateProductRequest) (*productv1.CreateProductResponse, error) {
+	id, err := s.store.CreateProduct(ctx, fromProduct(req.GetProduct()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &productv1.CreateProductResponse{Id: int32(id)}, nil
+}
+
+func (s *Server) GetProductByID(ctx context.Context, req *productv1.GetProductByIDRequest) (*productv1.Product, error) {
+	product, err := s.store.GetProductByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toProduct(*product), nil
+}
+
+func (s *Server) GetProductsByIDs(ctx context.Context, req *productv1.GetProductsByIDsRequest) (*productv1.ProductList, error) {
+	ids := make([]int, len(req.GetIds()))
+	for i, id := range req.GetIds() {
+		ids[i] = int(id)
+	}
+
+	products, err := s.store.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toProductList(products), nil
+}
+
+func (s *Server) UpdateProduct(ctx context.Context, req *productv1.UpdateProductRequest) (*emptypb.Empty, error) {
+	if err := s.store.UpdateProduct(ctx, int(req.GetId()), fromProduct(req.GetProduct())); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) DeleteProduct(ctx context.Context, req *productv1.DeleteProductRequest) (*emptypb.Empty, error) {
+	if err := s.store.DeleteProduct(ctx, int(req.GetId())); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetProductsByName(ctx context.Context, req *productv1.GetProductsByNameRequest) (*productv1.ProductList, error) {
+	products, err := s.store.GetProductsByName(ctx, req.GetName())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toProductList(products), nil
+}
+
+func (s *Server) GetProductsByCategory(ctx context.Context, req *productv1.GetProductsByCategoryRequest) (*productv1.ProductList, error) {
+	products, err := s.store.GetProductsByCategory(ctx, req.GetCategory())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toProductList(products), nil
+}
+
+func toProduct(product types.Product) *productv1.Product {
+	return &productv1.Product{
+		Id:          int32(product.ID),
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Quantity:    int32(product.Quantity),
+		Category:    product.Category,
+		CreatedAt:   timestamppb.New(product.CreatedAt),
+		UpdatedAt:   timestamppb.New(product.UpdatedAt),
+	}
+}
+
+func fromProduct(product *productv1.Product) types.Product {
+	return types.Product{
+		ID:          int(product.GetId()),
+		Name:        product.GetName(),
+		Description: product.GetDescription(),
+		Price:       product.GetPrice(),
+		Quantity:    int(product.GetQuantity()),
+		Category:    product.GetCategory(),
+		CreatedAt:   product.GetCreatedAt().AsTime(),
+		UpdatedAt:   product.GetUpdatedAt().AsTime(),
+	}
+}
+
+func toProductList(products []types.Product) *productv1.ProductList {
+	list := &productv1.ProductList{Products: make([]*productv1.Product, len(products))}
+	for i, product := range products {
+		list.Products[i] = toProduct(product)
+	}
+
+	return list
+}
+
+func fromProductList(list *productv1.ProductList) []types.Product {
+	products := make([]types.Product, len(list.GetProducts()))
+	for i, product := range list.GetProducts() {
+		products[i] = fromProduct(product)
+	}
+
+	return products
+}
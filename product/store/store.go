@@ -1,12 +1,17 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
+	"github.com/4lerman/e_com/common/tracing"
 	"github.com/4lerman/e_com/product/types"
+	"github.com/lib/pq"
 )
 
+const productColumns = "id, name, description, price, category, quantity, createdAt, updatedAt"
+
 type Store struct {
 	db *sql.DB
 }
@@ -17,8 +22,11 @@ func NewStore(db *sql.DB) *Store {
 	}
 }
 
-func (s *Store) GetProducts() ([]types.Product, error) {
-	rows, err := s.db.Query("SELECT * FROM products")
+func (s *Store) GetProducts(ctx context.Context) ([]types.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductStore.GetProducts")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+productColumns+" FROM products")
 
 	if err != nil {
 		return nil, err
@@ -37,8 +45,35 @@ func (s *Store) GetProducts() ([]types.Product, error) {
 	return products, nil
 }
 
-func (s *Store) GetProductByID(productId int) (*types.Product, error) {
-	rows, err := s.db.Query("SELECT * FROM products WHERE id = $1", productId)
+func (s *Store) GetProductsByIDs(ctx context.Context, productIds []int) ([]types.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductStore.GetProductsByIDs")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = ANY($1)", pq.Array(productIds))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	products := []types.Product{}
+	for rows.Next() {
+		product, err := scanRowIntoProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, *product)
+	}
+
+	return products, nil
+}
+
+func (s *Store) GetProductByID(ctx context.Context, productId int) (*types.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductStore.GetProductByID")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1", productId)
 	if err != nil {
 		return nil, err
 	}
@@ -55,26 +90,33 @@ func (s *Store) GetProductByID(productId int) (*types.Product, error) {
 	}
 
 	if product.ID == 0 {
-		return nil, fmt.Errorf("product not found")
+		return nil, types.ErrProductNotFound
 	}
 
 	return product, nil
 }
 
-func (s *Store) CreateProduct(product types.Product) error {
-	_, err := s.db.Exec("INSERT INTO products (name, description, price, quantity, category)"+
-		"VALUES ($1, $2, $3, $4, $5)", product.Name, product.Description, product.Price, product.Quantity, product.Category)
+func (s *Store) CreateProduct(ctx context.Context, product types.Product) (int, error) {
+	ctx, span := tracing.Start(ctx, "ProductStore.CreateProduct")
+	defer span.End()
+
+	var id int
+	err := s.db.QueryRowContext(ctx, "INSERT INTO products (name, description, price, quantity, category)"+
+		"VALUES ($1, $2, $3, $4, $5) RETURNING id", product.Name, product.Description, product.Price, product.Quantity, product.Category).Scan(&id)
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return id, nil
 }
 
-func (s *Store) UpdateProduct(productId int, product types.Product) error {
-	_, err := s.db.Exec("UPDATE products SET "+
-		"name = $1, description = $2, price = $3, quantity = $4, category = $5  WHERE id = $6",
+func (s *Store) UpdateProduct(ctx context.Context, productId int, product types.Product) error {
+	ctx, span := tracing.Start(ctx, "ProductStore.UpdateProduct")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE products SET "+
+		"name = $1, description = $2, price = $3, quantity = $4, category = $5, updatedAt = CURRENT_TIMESTAMP WHERE id = $6",
 		product.Name, product.Description, product.Price, product.Quantity, product.Category, productId)
 
 	if err != nil {
@@ -84,8 +126,11 @@ func (s *Store) UpdateProduct(productId int, product types.Product) error {
 	return nil
 }
 
-func (s *Store) DeleteProduct(productId int) error {
-	_, err := s.db.Exec("DELETE FROM products WHERE id = $1", productId)
+func (s *Store) DeleteProduct(ctx context.Context, productId int) error {
+	ctx, span := tracing.Start(ctx, "ProductStore.DeleteProduct")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM products WHERE id = $1", productId)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
@@ -94,8 +139,11 @@ func (s *Store) DeleteProduct(productId int) error {
 	return nil
 }
 
-func (s *Store) GetProductsByName(name string) ([]types.Product, error) {
-	rows, err := s.db.Query("SELECT * FROM products WHERE name ILIKE $1", "%"+name+"%")
+func (s *Store) GetProductsByName(ctx context.Context, name string) ([]types.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductStore.GetProductsByName")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+productColumns+" FROM products WHERE name ILIKE $1", "%"+name+"%")
 
 	if err != nil {
 		return nil, err
@@ -115,8 +163,11 @@ func (s *Store) GetProductsByName(name string) ([]types.Product, error) {
 	return products, nil
 }
 
-func (s *Store) GetProductsByCategory(category string) ([]types.Product, error) {
-	rows, err := s.db.Query("SELECT * FROM products WHERE category = $1", "%"+category+"%")
+func (s *Store) GetProductsByCategory(ctx context.Context, category string) ([]types.Product, error) {
+	ctx, span := tracing.Start(ctx, "ProductStore.GetProductsByCategory")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+productColumns+" FROM products WHERE category = $1", "%"+category+"%")
 
 	if err != nil {
 		return nil, err
@@ -147,6 +198,7 @@ func scanRowIntoProduct(rows *sql.Rows) (*types.Product, error) {
 		&product.Category,
 		&product.Quantity,
 		&product.CreatedAt,
+		&product.UpdatedAt,
 	)
 
 	if err != nil {
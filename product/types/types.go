@@ -1,15 +1,23 @@
 package types
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrProductNotFound is returned for products that do not exist.
+var ErrProductNotFound = errors.New("product not found")
 
 type ProductStore interface {
-	GetProducts() ([]Product, error)
-	CreateProduct(Product) error
-	GetProductByID(int) (*Product, error)
-	UpdateProduct(int, Product) error
-	DeleteProduct(int) error
-	GetProductsByName(string) ([]Product, error)
-	GetProductsByCategory(string) ([]Product, error)
+	GetProducts(context.Context) ([]Product, error)
+	CreateProduct(context.Context, Product) (int, error)
+	GetProductByID(context.Context, int) (*Product, error)
+	GetProductsByIDs(context.Context, []int) ([]Product, error)
+	UpdateProduct(context.Context, int, Product) error
+	DeleteProduct(context.Context, int) error
+	GetProductsByName(context.Context, string) ([]Product, error)
+	GetProductsByCategory(context.Context, string) ([]Product, error)
 }
 
 type Product struct {
@@ -20,6 +28,7 @@ type Product struct {
 	Quantity    int       `json:"quantity"`
 	Category    string    `json:"category"`
 	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
 type CreateProductPayload struct {
@@ -0,0 +1,383 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: payment/v1/payment.proto
+
+package paymentv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	PaymentStore_CreatePayment_FullMethodName        = "/payment.v1.PaymentStore/CreatePayment"
+	PaymentStore_DeletePayment_FullMethodName        = "/payment.v1.PaymentStore/DeletePayment"
+	PaymentStore_GetPaymentById_FullMethodName       = "/payment.v1.PaymentStore/GetPaymentById"
+	PaymentStore_ListPayments_FullMethodName         = "/payment.v1.PaymentStore/ListPayments"
+	PaymentStore_UpdatePayment_FullMethodName        = "/payment.v1.PaymentStore/UpdatePayment"
+	PaymentStore_GetPaymentsByStatus_FullMethodName  = "/payment.v1.PaymentStore/GetPaymentsByStatus"
+	PaymentStore_GetPaymentsByUserId_FullMethodName  = "/payment.v1.PaymentStore/GetPaymentsByUserId"
+	PaymentStore_GetPaymentsByOrderId_FullMethodName = "/payment.v1.PaymentStore/GetPaymentsByOrderId"
+)
+
+// PaymentStoreClient is the client API for PaymentStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PaymentStore exposes the payment service's store over gRPC. It records
+// payments only; charging the provider stays behind the REST API.
+type PaymentStoreClient interface {
+	CreatePayment(ctx context.Context, in *CreatePaymentRequest, opts ...grpc.CallOption) (*CreatePaymentResponse, error)
+	DeletePayment(ctx context.Context, in *DeletePaymentRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetPaymentById(ctx context.Context, in *GetPaymentByIdRequest, opts ...grpc.CallOption) (*Payment, error)
+	ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*PaymentList, error)
+	UpdatePayment(ctx context.Context, in *UpdatePaymentRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetPaymentsByStatus(ctx context.Context, in *GetPaymentsByStatusRequest, opts ...grpc.CallOption) (*PaymentList, error)
+	GetPaymentsByUserId(ctx context.Context, in *GetPaymentsByUserIdRequest, opts ...grpc.CallOption) (*PaymentList, error)
+	GetPaymentsByOrderId(ctx context.Context, in *GetPaymentsByOrderIdRequest, opts ...grpc.CallOption) (*PaymentList, error)
+}
+
+type paymentStoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPaymentStoreClient(cc grpc.ClientConnInterface) PaymentStoreClient {
+	return &paymentStoreClient{cc}
+}
+
+func (c *paymentStoreClient) CreatePayment(ctx context.Context, in *CreatePaymentRequest, opts ...grpc.CallOption) (*CreatePaymentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreatePaymentResponse)
+	err := c.cc.Invoke(ctx, PaymentStore_CreatePayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentStoreClient) DeletePayment(ctx context.Context, in *DeletePaymentRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, PaymentStore_DeletePayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentStoreClient) GetPaymentById(ctx context.Context, in *GetPaymentByIdRequest, opts ...grpc.CallOption) (*Payment, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Payment)
+	err := c.cc.Invoke(ctx, PaymentStore_GetPaymentById_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentStoreClient) ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*PaymentList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PaymentList)
+	err := c.cc.Invoke(ctx, PaymentStore_ListPayments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentStoreClient) UpdatePayment(ctx context.Context, in *UpdatePaymentRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, PaymentStore_UpdatePayment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentStoreClient) GetPaymentsByStatus(ctx context.Context, in *GetPaymentsByStatusRequest, opts ...grpc.CallOption) (*PaymentList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PaymentList)
+	err := c.cc.Invoke(ctx, PaymentStore_GetPaymentsByStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentStoreClient) GetPaymentsByUserId(ctx context.Context, in *GetPaymentsByUserIdRequest, opts ...grpc.CallOption) (*PaymentList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PaymentList)
+	err := c.cc.Invoke(ctx, PaymentStore_GetPaymentsByUserId_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentStoreClient) GetPaymentsByOrderId(ctx context.Context, in *GetPaymentsByOrderIdRequest, opts ...grpc.CallOption) (*PaymentList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PaymentList)
+	err := c.cc.Invoke(ctx, PaymentStore_GetPaymentsByOrderId_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaymentStoreServer is the server API for PaymentStore service.
+// All implementations must embed UnimplementedPaymentStoreServer
+// for forward compatibility
+//
+// PaymentStore exposes the payment service's store over gRPC. It records
+// payments only; charging the provider stays behind the REST API.
+type PaymentStoreServer interface {
+	CreatePayment(context.Context, *CreatePaymentRequest) (*CreatePaymentResponse, error)
+	DeletePayment(context.Context, *DeletePaymentRequest) (*emptypb.Empty, error)
+	GetPaymentById(context.Context, *GetPaymentByIdRequest) (*Payment, error)
+	ListPayments(context.Context, *ListPaymentsRequest) (*PaymentList, error)
+	UpdatePayment(context.Context, *UpdatePaymentRequest) (*emptypb.Empty, error)
+	GetPaymentsByStatus(context.Context, *GetPaymentsByStatusRequest) (*PaymentList, error)
+	GetPaymentsByUserId(context.Context, *GetPaymentsByUserIdRequest) (*PaymentList, error)
+	GetPaymentsByOrderId(context.Context, *GetPaymentsByOrderIdRequest) (*PaymentList, error)
+	mustEmbedUnimplementedPaymentStoreServer()
+}
+
+// UnimplementedPaymentStoreServer must be embedded to have forward compatible implementations.
+type UnimplementedPaymentStoreServer struct {
+}
+
+func (UnimplementedPaymentStoreServer) CreatePayment(context.Context, *CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePayment not implemented")
+}
+func (UnimplementedPaymentStoreServer) DeletePayment(context.Context, *DeletePaymentRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePayment not implemented")
+}
+func (UnimplementedPaymentStoreServer) GetPaymentById(context.Context, *GetPaymentByIdRequest) (*Payment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentById not implemented")
+}
+func (UnimplementedPaymentStoreServer) ListPayments(context.Context, *ListPaymentsRequest) (*PaymentList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPayments not implemented")
+}
+func (UnimplementedPaymentStoreServer) UpdatePayment(context.Context, *UpdatePaymentRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePayment not implemented")
+}
+func (UnimplementedPaymentStoreServer) GetPaymentsByStatus(context.Context, *GetPaymentsByStatusRequest) (*PaymentList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentsByStatus not implemented")
+}
+func (UnimplementedPaymentStoreServer) GetPaymentsByUserId(context.Context, *GetPaymentsByUserIdRequest) (*PaymentList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentsByUserId not implemented")
+}
+func (UnimplementedPaymentStoreServer) GetPaymentsByOrderId(context.Context, *GetPaymentsByOrderIdRequest) (*PaymentList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentsByOrderId not implemented")
+}
+func (UnimplementedPaymentStoreServer) mustEmbedUnimplementedPaymentStoreServer() {}
+
+// UnsafePaymentStoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PaymentStoreServer will
+// result in compilation errors.
+type UnsafePaymentStoreServer interface {
+	mustEmbedUnimplementedPaymentStoreServer()
+}
+
+func RegisterPaymentStoreServer(s grpc.ServiceRegistrar, srv PaymentStoreServer) {
+	s.RegisterService(&PaymentStore_ServiceDesc, srv)
+}
+
+func _PaymentStore_CreatePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentStoreServer).CreatePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentStore_CreatePayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentStoreServer).CreatePayment(ctx, req.(*CreatePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentStore_DeletePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentStoreServer).DeletePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentStore_DeletePayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentStoreServer).DeletePayment(ctx, req.(*DeletePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentStore_GetPaymentById_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentByIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentStoreServer).GetPaymentById(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentStore_GetPaymentById_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentStoreServer).GetPaymentById(ctx, req.(*GetPaymentByIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentStore_ListPayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentStoreServer).ListPayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentStore_ListPayments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentStoreServer).ListPayments(ctx, req.(*ListPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentStore_UpdatePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentStoreServer).UpdatePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentStore_UpdatePayment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentStoreServer).UpdatePayment(ctx, req.(*UpdatePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentStore_GetPaymentsByStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentsByStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentStoreServer).GetPaymentsByStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentStore_GetPaymentsByStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentStoreServer).GetPaymentsByStatus(ctx, req.(*GetPaymentsByStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentStore_GetPaymentsByUserId_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentsByUserIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentStoreServer).GetPaymentsByUserId(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentStore_GetPaymentsByUserId_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentStoreServer).GetPaymentsByUserId(ctx, req.(*GetPaymentsByUserIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentStore_GetPaymentsByOrderId_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentsByOrderIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentStoreServer).GetPaymentsByOrderId(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentStore_GetPaymentsByOrderId_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentStoreServer).GetPaymentsByOrderId(ctx, req.(*GetPaymentsByOrderIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PaymentStore_ServiceDesc is the grpc.ServiceDesc for PaymentStore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PaymentStore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "payment.v1.PaymentStore",
+	HandlerType: (*PaymentStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePayment",
+			Handler:    _PaymentStore_CreatePayment_Handler,
+		},
+		{
+			MethodName: "DeletePayment",
+			Handler:    _PaymentStore_DeletePayment_Handler,
+		},
+		{
+			MethodName: "GetPaymentById",
+			Handler:    _PaymentStore_GetPaymentById_Handler,
+		},
+		{
+			MethodName: "ListPayments",
+			Handler:    _PaymentStore_ListPayments_Handler,
+		},
+		{
+			MethodName: "UpdatePayment",
+			Handler:    _PaymentStore_UpdatePayment_Handler,
+		},
+		{
+			MethodName: "GetPaymentsByStatus",
+			Handler:    _PaymentStore_GetPaymentsByStatus_Handler,
+		},
+		{
+			MethodName: "GetPaymentsByUserId",
+			Handler:    _PaymentStore_GetPaymentsByUserId_Handler,
+		},
+		{
+			MethodName: "GetPaymentsByOrderId",
+			Handler:    _PaymentStore_GetPaymentsByOrderId_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "payment/v1/payment.proto",
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,51 +11,110 @@ import (
 
 	configs "github.com/4lerman/e_com/common/config"
 	"github.com/4lerman/e_com/common/db"
+	"github.com/4lerman/e_com/common/health"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/mail"
+	"github.com/4lerman/e_com/common/metrics"
+	commonRpc "github.com/4lerman/e_com/common/rpc"
+	"github.com/4lerman/e_com/common/tracing"
+	userv1 "github.com/4lerman/e_com/proto/user/v1"
 	"github.com/4lerman/e_com/user/routes"
+	userRpc "github.com/4lerman/e_com/user/rpc"
+	"github.com/4lerman/e_com/user/service"
 	"github.com/4lerman/e_com/user/store"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"google.golang.org/grpc"
 )
 
 func main() {
+	logger.Init("users")
+
+	if err := configs.CheckSecret("JWT_SECRET", configs.Envs.JWT_Secret); err != nil {
+		logger.Fatal("invalid configuration", err)
+	}
+	if err := configs.CheckSecret("INTERNAL_SECRET", configs.Envs.Internal_Secret); err != nil {
+		logger.Fatal("invalid configuration", err)
+	}
+
+	shutdownTracing, err := tracing.Init("users")
+	if err != nil {
+		logger.Fatal("tracing setup failed", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := db.InitStorage()
 	if err != nil {
-		log.Fatal(err)
+		logger.Fatal("db connection failed", err)
 	}
 
-	log.Println("Db connected successfully!")
+	slog.Info("db connected")
+	metrics.RegisterDB(db, "users")
 
 	userStore := store.NewStore(db)
-	userHandler := routes.NewHandler(userStore)
-	
+
+	if configs.Envs.Admin_Email != "" && configs.Envs.Admin_Password != "" {
+		if err := service.EnsureAdmin(context.Background(), userStore, configs.Envs.Admin_Email, configs.Envs.Admin_Password); err != nil {
+			logger.Fatal("admin bootstrap failed", err)
+		}
+	}
+
+	mailer, err := mail.New()
+	if err != nil {
+		logger.Fatal("mailer setup failed", err)
+	}
+
+	userHandler := routes.NewHandler(userStore, userStore, service.NewMailNotifier(mailer))
+	roleHandler := routes.NewRoleHandler(userStore)
+	sessionHandler := routes.NewSessionHandler(userStore, userStore)
+
 	router := mux.NewRouter()
+	router.Use(tracing.Middleware, logger.Middleware, metrics.Middleware, identity.Internal(configs.Envs.Internal_Secret))
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	health.Register(router, map[string]health.Check{
+		"db": health.DB(db),
+	})
+
 	userRouter := router.PathPrefix("/users").Subrouter()
 	userHandler.RegisterRoutes(userRouter)
+	sessionHandler.RegisterUserRoutes(userRouter)
+	roleHandler.RegisterRoutes(router)
+	sessionHandler.RegisterRoutes(router)
 
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{configs.Envs.Base_Url},
 		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
 		AllowedHeaders:   []string{"Content-Type"},
 		AllowCredentials: true,
-	}).Handler(userRouter)
+	}).Handler(router)
 
 	port := configs.Envs.Users_Port
 	server := &http.Server{
-		Addr:   ":" + port,
+		Addr:    ":" + port,
 		Handler: corsHandler,
 	}
 
-	go gracefulShutdown(server)
+	grpcServer := commonRpc.NewServer()
+	userv1.RegisterUserStoreServer(grpcServer, userRpc.NewServer(userStore))
 
-	log.Printf("Server(USERS) is starting on port %s\n", port)
+	go func() {
+		if err := commonRpc.Serve(grpcServer, configs.Envs.Users_Grpc_Port); err != nil {
+			logger.Fatal("grpc server startup failed", err)
+		}
+	}()
+
+	go gracefulShutdown(server, grpcServer)
+
+	slog.Info("server is starting", "port", port)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server startup failed: %v\n", err)
+		logger.Fatal("server startup failed", err)
 	}
 
-	log.Println("Server(USERS) gracefully stopped")
+	slog.Info("server gracefully stopped")
 }
 
-func gracefulShutdown(server *http.Server) {
+func gracefulShutdown(server *http.Server, grpcServer *grpc.Server) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
@@ -65,6 +124,8 @@ func gracefulShutdown(server *http.Server) {
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Graceful shutdown failed: %v\n", err)
+		logger.Fatal("graceful shutdown failed", err)
 	}
-}
\ No newline at end of file
+
+	grpcServer.GracefulStop()
+}
@@ -0,0 +1,181 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/4lerman/e_com/user/types"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) handleListAddresses(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.addressBook(w, r)
+	if !ok {
+		return
+	}
+
+	addresses, err := h.store.ListAddresses(r.Context(), userId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, addresses)
+}
+
+func (h *Handler) handleCreateAddress(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.addressBook(w, r)
+	if !ok {
+		return
+	}
+
+	address, ok := parseAddress(w, r)
+	if !ok {
+		return
+	}
+	address.UserID = userId
+
+	id, err := h.store.CreateAddress(r.Context(), address)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteCreated(w, r, fmt.Sprintf("/users/%d/addresses/%d", userId, id), func() (any, error) {
+		return h.store.GetAddress(r.Context(), userId, id)
+	})
+}
+
+func (h *Handler) handleGetAddress(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.addressBook(w, r)
+	if !ok {
+		return
+	}
+
+	addressId, err := strconv.Atoi(mux.Vars(r)["addressId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid address id"))
+		return
+	}
+
+	address, err := h.store.GetAddress(r.Context(), userId, addressId)
+	if errors.Is(err, types.ErrAddressNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, address)
+}
+
+func (h *Handler) handleUpdateAddress(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.addressBook(w, r)
+	if !ok {
+		return
+	}
+
+	addressId, err := strconv.Atoi(mux.Vars(r)["addressId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid address id"))
+		return
+	}
+
+	address, ok := parseAddress(w, r)
+	if !ok {
+		return
+	}
+	address.ID = addressId
+	address.UserID = userId
+
+	err = h.store.UpdateAddress(r.Context(), address)
+	if errors.Is(err, types.ErrAddressNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Updated successfully"})
+}
+
+func (h *Handler) handleDeleteAddress(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.addressBook(w, r)
+	if !ok {
+		return
+	}
+
+	addressId, err := strconv.Atoi(mux.Vars(r)["addressId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid address id"))
+		return
+	}
+
+	err = h.store.DeleteAddress(r.Context(), userId, addressId)
+	if errors.Is(err, types.ErrAddressNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Deleted successfully"})
+}
+
+// addressBook returns the user whose addresses are requested, after
+// checking that the user exists and that the caller may manage them:
+// clients only manage their own.
+func (h *Handler) addressBook(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return 0, false
+	}
+
+	if caller, restricted := identity.Restricted(r); restricted && caller.UserID != userId {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("cannot access the addresses of another user"))
+		return 0, false
+	}
+
+	if _, err := h.store.GetUserById(r.Context(), userId); err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
+		return 0, false
+	}
+
+	return userId, true
+}
+
+func parseAddress(w http.ResponseWriter, r *http.Request) (types.Address, bool) {
+	var payload types.AddressPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return types.Address{}, false
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return types.Address{}, false
+	}
+
+	return types.Address{
+		Label:           payload.Label,
+		Country:         strings.ToUpper(payload.Country),
+		City:            payload.City,
+		Street:          payload.Street,
+		PostalCode:      payload.PostalCode,
+		Phone:           payload.Phone,
+		DefaultShipping: payload.DefaultShipping,
+		DefaultBilling:  payload.DefaultBilling,
+	}, true
+}
@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/4lerman/e_com/user/types"
+	"github.com/gorilla/mux"
+)
+
+// handleExportUser answers with the personal data the service holds about
+// the user and logs the export. Clients only export their own.
+func (h *Handler) handleExportUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	if caller, restricted := identity.Restricted(r); restricted && caller.UserID != userId {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("cannot export the data of another user"))
+		return
+	}
+
+	user, err := h.store.GetUserById(r.Context(), userId)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
+		return
+	}
+
+	addresses, err := h.store.ListAddresses(r.Context(), userId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// nothing is handed out that is not on the log
+	if err := h.store.LogDataRequest(r.Context(), dataRequest(r, userId, types.Export)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PersonalData{
+		Profile:   *user,
+		Addresses: addresses,
+	})
+}
+
+// handleDeleteUser erases the personal data of the user. The account is
+// kept anonymised, so its orders and payments stay intact. Clients only
+// erase their own.
+func (h *Handler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	if caller, restricted := identity.Restricted(r); restricted && caller.UserID != userId {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("cannot erase another user"))
+		return
+	}
+
+	if _, err := h.store.GetUserById(r.Context(), userId); err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
+		return
+	}
+
+	err = h.store.EraseUser(r.Context(), dataRequest(r, userId, types.Erasure))
+	if errors.Is(err, types.ErrUserErased) {
+		utils.WriteError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "User data erased"})
+}
+
+// handleListDataRequests answers with the compliance log of the user's
+// exports and erasures. Only admins may read it.
+func (h *Handler) handleListDataRequests(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	if !admin(r) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("only admins may read data requests"))
+		return
+	}
+
+	requests, err := h.store.ListDataRequests(r.Context(), userId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, requests)
+}
+
+// dataRequest is the log entry for a request about the user, made by the
+// caller or, for internal calls, by nobody in particular.
+func dataRequest(r *http.Request, userId int, kind types.DataRequestKind) types.DataRequest {
+	request := types.DataRequest{
+		UserID: userId,
+		Kind:   kind,
+	}
+
+	if caller, ok := identity.FromRequest(r); ok {
+		request.RequestedBy = &caller.UserID
+		request.RequestedByRole = caller.Role
+	}
+
+	return request
+}
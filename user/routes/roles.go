@@ -0,0 +1,226 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/4lerman/e_com/user/types"
+	"github.com/gorilla/mux"
+)
+
+// RoleHandler serves the roles and the permission catalog. The gateway
+// only lets admins at them, and asks /permissions/check what the roles of
+// other callers may do.
+type RoleHandler struct {
+	store types.RoleStore
+}
+
+func NewRoleHandler(store types.RoleStore) *RoleHandler {
+	return &RoleHandler{
+		store: store,
+	}
+}
+
+func (h *RoleHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/roles", h.handleListRoles).Methods(http.MethodGet)
+	router.HandleFunc("/roles", h.handleCreateRole).Methods(http.MethodPost)
+	router.HandleFunc("/roles/{name}", h.handleGetRole).Methods(http.MethodGet)
+	router.HandleFunc("/roles/{name}", h.handleUpdateRole).Methods(http.MethodPut)
+	router.HandleFunc("/roles/{name}", h.handleDeleteRole).Methods(http.MethodDelete)
+	router.HandleFunc("/roles/{name}/permissions/{permission}", h.handleAddPermission).Methods(http.MethodPut)
+	router.HandleFunc("/roles/{name}/permissions/{permission}", h.handleRemovePermission).Methods(http.MethodDelete)
+	router.HandleFunc("/permissions", h.handleListPermissions).Methods(http.MethodGet)
+	router.HandleFunc("/permissions/check", h.handleCheckPermission).Methods(http.MethodGet)
+}
+
+func (h *RoleHandler) handleListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.store.ListRoles(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) handleCreateRole(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateRolePayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	// API key clients call with the service role
+	if string(payload.Name) == identity.Service {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("role name %s is reserved", payload.Name))
+		return
+	}
+
+	err := h.store.CreateRole(r.Context(), types.Role{
+		Name:        payload.Name,
+		Description: payload.Description,
+		Permissions: payload.Permissions,
+	})
+	if !writeRoleError(w, err) {
+		return
+	}
+
+	utils.WriteCreated(w, r, fmt.Sprintf("/roles/%s", payload.Name), func() (any, error) {
+		return h.store.GetRole(r.Context(), string(payload.Name))
+	})
+}
+
+func (h *RoleHandler) handleGetRole(w http.ResponseWriter, r *http.Request) {
+	role, err := h.store.GetRole(r.Context(), mux.Vars(r)["name"])
+	if !writeRoleError(w, err) {
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, role)
+}
+
+func (h *RoleHandler) handleUpdateRole(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.customRole(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.UpdateRolePayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	role.Description = payload.Description
+	role.Permissions = payload.Permissions
+
+	if !writeRoleError(w, h.store.UpdateRole(r.Context(), *role)) {
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Updated successfully"})
+}
+
+func (h *RoleHandler) handleDeleteRole(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.customRole(w, r)
+	if !ok {
+		return
+	}
+
+	if !writeRoleError(w, h.store.DeleteRole(r.Context(), string(role.Name))) {
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Deleted successfully"})
+}
+
+func (h *RoleHandler) handleAddPermission(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.customRole(w, r)
+	if !ok {
+		return
+	}
+
+	if !writeRoleError(w, h.store.AddPermission(r.Context(), string(role.Name), mux.Vars(r)["permission"])) {
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Permission added"})
+}
+
+func (h *RoleHandler) handleRemovePermission(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.customRole(w, r)
+	if !ok {
+		return
+	}
+
+	if !writeRoleError(w, h.store.RemovePermission(r.Context(), string(role.Name), mux.Vars(r)["permission"])) {
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Permission removed"})
+}
+
+func (h *RoleHandler) handleListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.store.ListPermissions(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, permissions)
+}
+
+// handleCheckPermission answers whether the role holds the permission.
+// Admins hold every permission; unknown roles and permissions none.
+func (h *RoleHandler) handleCheckPermission(w http.ResponseWriter, r *http.Request) {
+	role := r.URL.Query().Get("role")
+	permission := r.URL.Query().Get("permission")
+
+	if role == "" || permission == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("role and permission query parameters are required"))
+		return
+	}
+
+	allowed := types.UserRole(role) == types.Admin
+	if !allowed {
+		var err error
+		allowed, err = h.store.HasPermission(r.Context(), role, permission)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.PermissionCheck{
+		Role:       types.UserRole(role),
+		Permission: permission,
+		Allowed:    allowed,
+	})
+}
+
+// customRole returns the role named in the path, unless it is built in.
+func (h *RoleHandler) customRole(w http.ResponseWriter, r *http.Request) (*types.Role, bool) {
+	role, err := h.store.GetRole(r.Context(), mux.Vars(r)["name"])
+	if !writeRoleError(w, err) {
+		return nil, false
+	}
+
+	if role.BuiltIn {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("built-in role %s cannot be changed", role.Name))
+		return nil, false
+	}
+
+	return role, true
+}
+
+// writeRoleError answers with the status for err and reports whether there
+// was none.
+func writeRoleError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, types.ErrRoleNotFound):
+		utils.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, types.ErrRoleExists), errors.Is(err, types.ErrRoleInUse):
+		utils.WriteError(w, http.StatusConflict, err)
+	case errors.Is(err, types.ErrPermissionNotFound):
+		utils.WriteError(w, http.StatusBadRequest, err)
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, err)
+	}
+
+	return false
+}
@@ -1,23 +1,33 @@
 package routes
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
 	"github.com/4lerman/e_com/common/utils"
+	"github.com/4lerman/e_com/user/service"
 	"github.com/4lerman/e_com/user/types"
-	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
-	store types.UserStore
+	store    types.UserStore
+	roles    types.RoleStore
+	notifier service.Notifier
 }
 
-func NewHandler(store types.UserStore) *Handler {
+func NewHandler(store types.UserStore, roles types.RoleStore, notifier service.Notifier) *Handler {
 	return &Handler{
-		store: store,
+		store:    store,
+		roles:    roles,
+		notifier: notifier,
 	}
 }
 
@@ -25,13 +35,28 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("", h.handleListUsers).Methods(http.MethodGet)
 	router.HandleFunc("", h.handleCreateUser).Methods(http.MethodPost)
 	router.HandleFunc("/search", h.handleUserByNameOrEmail).Methods(http.MethodGet)
+	router.HandleFunc("/credentials", h.handleCheckCredentials).Methods(http.MethodPost)
+	router.HandleFunc("/register", h.handleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/verify-email", h.handleVerifyEmail).Methods(http.MethodPost)
+	router.HandleFunc("/verify-email/resend", h.handleResendVerification).Methods(http.MethodPost)
+	router.HandleFunc("/password-reset", h.handleRequestPasswordReset).Methods(http.MethodPost)
+	router.HandleFunc("/password-reset/confirm", h.handleConfirmPasswordReset).Methods(http.MethodPost)
+	router.HandleFunc("/{id}/password", h.handleChangePassword).Methods(http.MethodPut)
+	router.HandleFunc("/{id}/role", h.handleAssignRole).Methods(http.MethodPut)
+	router.HandleFunc("/{id}/addresses", h.handleListAddresses).Methods(http.MethodGet)
+	router.HandleFunc("/{id}/addresses", h.handleCreateAddress).Methods(http.MethodPost)
+	router.HandleFunc("/{id}/addresses/{addressId}", h.handleGetAddress).Methods(http.MethodGet)
+	router.HandleFunc("/{id}/addresses/{addressId}", h.handleUpdateAddress).Methods(http.MethodPut)
+	router.HandleFunc("/{id}/addresses/{addressId}", h.handleDeleteAddress).Methods(http.MethodDelete)
+	router.HandleFunc("/{id}/export", h.handleExportUser).Methods(http.MethodGet)
+	router.HandleFunc("/{id}/data-requests", h.handleListDataRequests).Methods(http.MethodGet)
 	router.HandleFunc("/{id}", h.handleGetUserById).Methods(http.MethodGet)
 	router.HandleFunc("/{id}", h.handleUpdateUser).Methods(http.MethodPut)
 	router.HandleFunc("/{id}", h.handleDeleteUser).Methods(http.MethodDelete)
 }
 
 func (h *Handler) handleListUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.store.ListUsers()
+	users, err := h.store.ListUsers(r.Context())
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -44,30 +69,83 @@ func (h *Handler) handleListUsers(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	var payload types.CreateUserPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	// only admins may create accounts with another role
+	if !admin(r) {
+		payload.UserRole = types.Client
+	} else if !h.checkRole(w, r, payload.UserRole) {
 		return
 	}
 
-	err := h.store.CreateUser(types.User{
+	h.createUser(w, r, types.User{
 		FullName: payload.FullName,
 		Email:    payload.Email,
 		UserRole: payload.UserRole,
 		Address:  payload.Address,
-	})
+	}, payload.Password)
+}
+
+func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var payload types.RegisterPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	h.createUser(w, r, types.User{
+		FullName: payload.FullName,
+		Email:    payload.Email,
+		UserRole: types.Client,
+		Address:  payload.Address,
+	}, payload.Password)
+}
+
+// createUser creates the account, with the password if there is one, sends
+// the owner a link to verify the email and answers 201.
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request, user types.User, password string) {
+	if _, err := h.store.GetUserByEmail(r.Context(), user.Email); err == nil {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("email %s is already registered", user.Email))
+		return
+	}
+
+	if password != "" {
+		hash, err := service.HashPassword(password)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		user.PasswordHash = hash
+	}
+
+	id, err := h.store.CreateUser(r.Context(), user)
 
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, map[string]string{"msg": "Created successfully"})
+	// the account is there either way; the owner can ask for another link
+	user.ID = id
+	if err := h.sendVerification(r.Context(), user); err != nil {
+		logger.FromContext(r.Context()).Warn("failed to send email verification", "user_id", id, "error", err)
+	}
 
+	utils.WriteCreated(w, r, fmt.Sprintf("/users/%d", id), func() (any, error) {
+		return h.store.GetUserById(r.Context(), id)
+	})
 }
 
 func (h *Handler) handleGetUserById(w http.ResponseWriter, r *http.Request) {
@@ -81,7 +159,7 @@ func (h *Handler) handleGetUserById(w http.ResponseWriter, r *http.Request) {
 
 	userId, _ := strconv.Atoi(id)
 
-	user, err := h.store.GetUserById(userId)
+	user, err := h.store.GetUserById(r.Context(), userId)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
 		return
@@ -103,46 +181,50 @@ func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	var payload types.UpdateUserPayload
 	if err := utils.ReqParseJSON(r, &payload); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err)
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
 	if err := utils.Validate.Struct(payload); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteValidationError(w, r, err)
 		return
 	}
 
-	err := h.store.UpdateUser(userId, types.User{
-		FullName: payload.FullName,
-		UserRole: payload.UserRole,
-	})
-
+	user, err := h.store.GetUserById(r.Context(), userId)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Updated successfully"})
-}
-
-func (h *Handler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+	// only admins may change roles
+	if payload.UserRole != "" && payload.UserRole != user.UserRole {
+		if !admin(r) {
+			utils.WriteError(w, http.StatusForbidden, fmt.Errorf("changing user role is not allowed"))
+			return
+		}
+		if !h.checkRole(w, r, payload.UserRole) {
+			return
+		}
+	}
 
-	if id == "" {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("id is not indicated"))
-		return
+	if payload.FullName != "" {
+		user.FullName = payload.FullName
+	}
+	if payload.Address != "" {
+		user.Address = payload.Address
+	}
+	if payload.UserRole != "" {
+		user.UserRole = payload.UserRole
 	}
 
-	userId, _ := strconv.Atoi(id)
+	err = h.store.UpdateUser(r.Context(), userId, *user)
 
-	if err := h.store.DeleteUser(userId); err != nil {
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Deleted successfully"})
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Updated successfully"})
 }
 
 func (h *Handler) handleUserByNameOrEmail(w http.ResponseWriter, r *http.Request) {
@@ -153,9 +235,9 @@ func (h *Handler) handleUserByNameOrEmail(w http.ResponseWriter, r *http.Request
 	var err error
 
 	if name != "" {
-		users, err = h.store.GetUsersByName(name)
+		users, err = h.store.GetUsersByName(r.Context(), name)
 	} else if email != "" {
-		users, err = h.store.GetUsersByEmail(email)
+		users, err = h.store.GetUsersByEmail(r.Context(), email)
 	} else {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("either name or email query parameter is required"))
 		return
@@ -168,3 +250,291 @@ func (h *Handler) handleUserByNameOrEmail(w http.ResponseWriter, r *http.Request
 
 	utils.WriteJSON(w, http.StatusOK, users)
 }
+
+func (h *Handler) handleCheckCredentials(w http.ResponseWriter, r *http.Request) {
+	var payload types.CredentialsPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	user, err := h.store.GetUserByEmail(r.Context(), payload.Email)
+	if err != nil {
+		// take as long as for a wrong password
+		service.CheckPassword("", payload.Password)
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid email or password"))
+		return
+	}
+
+	if !service.CheckPassword(user.PasswordHash, payload.Password) {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid email or password"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, user)
+}
+
+func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	var payload types.ChangePasswordPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	// permissions to manage users do not extend to their passwords
+	caller, _ := identity.FromRequest(r)
+	restricted := !admin(r)
+	if restricted && caller.UserID != userId {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("cannot change the password of another user"))
+		return
+	}
+
+	user, err := h.store.GetUserById(r.Context(), userId)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
+		return
+	}
+
+	// users confirm their current password, admins may set a new one
+	if restricted && !service.CheckPassword(user.PasswordHash, payload.CurrentPassword) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("current password is incorrect"))
+		return
+	}
+
+	hash, err := service.HashPassword(payload.NewPassword)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// whoever else is signed in to the account may have known the old
+	// password, so only the caller's own session stays open
+	keepSession := 0
+	if caller.UserID == userId {
+		keepSession = caller.SessionID
+	}
+
+	if err := h.store.ChangePassword(r.Context(), userId, hash, keepSession); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Password changed"})
+}
+
+// handleAssignRole gives the user a role. Only admins may assign roles.
+func (h *Handler) handleAssignRole(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return
+	}
+
+	if !admin(r) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("only admins may assign roles"))
+		return
+	}
+
+	var payload types.AssignRolePayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if !h.checkRole(w, r, payload.Role) {
+		return
+	}
+
+	user, err := h.store.GetUserById(r.Context(), userId)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
+		return
+	}
+
+	user.UserRole = payload.Role
+	if err := h.store.UpdateUser(r.Context(), userId, *user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Role assigned"})
+}
+
+// checkRole answers 400 unless the role exists.
+func (h *Handler) checkRole(w http.ResponseWriter, r *http.Request, role types.UserRole) bool {
+	_, err := h.roles.GetRole(r.Context(), string(role))
+	if errors.Is(err, types.ErrRoleNotFound) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown role %s", role))
+		return false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+
+	return true
+}
+
+// admin reports whether the caller has full power over accounts, which only
+// admins have. Role changes and other users' passwords are left to them, so
+// neither a permission nor the scope of an API key can be turned into more.
+func admin(r *http.Request) bool {
+	caller, ok := identity.FromRequest(r)
+	return ok && caller.IsAdmin()
+}
+
+func (h *Handler) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var payload types.VerifyEmailPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	_, err := service.VerifyEmail(r.Context(), h.store, payload.Token)
+	if errors.Is(err, types.ErrInvalidVerificationToken) {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Email verified"})
+}
+
+// handleResendVerification sends a new verification link to the owner of
+// the email unless it is verified already. Like password resets, it answers
+// the same either way.
+func (h *Handler) handleResendVerification(w http.ResponseWriter, r *http.Request) {
+	var payload types.ResendVerificationPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	accepted := map[string]string{"msg": "If the email is registered and not verified yet, a verification link has been sent to it"}
+
+	user, err := h.store.GetUserByEmail(r.Context(), payload.Email)
+	if err != nil || user.EmailVerified || user.ErasedAt != nil {
+		utils.WriteJSON(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	if err := h.sendVerification(r.Context(), *user); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to send email verification: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, accepted)
+}
+
+func (h *Handler) sendVerification(ctx context.Context, user types.User) error {
+	expires := time.Now().Add(time.Duration(configs.Envs.Email_Verification_Ttl) * time.Second)
+	return h.notifier.SendVerification(ctx, user, service.NewVerificationToken(user, expires))
+}
+
+// handleRequestPasswordReset sends a reset token to the owner of the email.
+// It answers the same whether there is one, so it cannot be used to find
+// out who has an account.
+func (h *Handler) handleRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var payload types.PasswordResetPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	accepted := map[string]string{"msg": "If the email is registered, a reset link has been sent to it"}
+
+	user, err := h.store.GetUserByEmail(r.Context(), payload.Email)
+	if err != nil || user.ErasedAt != nil {
+		utils.WriteJSON(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	token, hash, err := service.NewResetToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ttl := time.Duration(configs.Envs.Password_Reset_Ttl) * time.Second
+	if err := h.store.CreatePasswordReset(r.Context(), user.ID, hash, ttl); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.notifier.SendPasswordReset(r.Context(), *user, token); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to send password reset: %w", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, accepted)
+}
+
+func (h *Handler) handleConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var payload types.ConfirmPasswordResetPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	hash, err := service.HashPassword(payload.Password)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = h.store.ResetPassword(r.Context(), service.HashResetToken(payload.Token), hash)
+	if errors.Is(err, types.ErrInvalidResetToken) {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Password changed"})
+}
@@ -0,0 +1,265 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/identity"
+	"github.com/4lerman/e_com/common/logger"
+	"github.com/4lerman/e_com/common/utils"
+	"github.com/4lerman/e_com/user/service"
+	"github.com/4lerman/e_com/user/types"
+	"github.com/gorilla/mux"
+)
+
+// SessionHandler serves sessions: /sessions to the gateway, which opens,
+// refreshes and checks them, and /users/{id}/sessions to their users.
+type SessionHandler struct {
+	store types.SessionStore
+	users types.UserStore
+}
+
+func NewSessionHandler(store types.SessionStore, users types.UserStore) *SessionHandler {
+	return &SessionHandler{
+		store: store,
+		users: users,
+	}
+}
+
+func (h *SessionHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/sessions", h.handleCreateSession).Methods(http.MethodPost)
+	router.HandleFunc("/sessions/refresh", h.handleRefreshSession).Methods(http.MethodPost)
+	router.HandleFunc("/sessions/{id}/check", h.handleCheckSession).Methods(http.MethodGet)
+}
+
+// RegisterUserRoutes registers the routes under /users on its router.
+func (h *SessionHandler) RegisterUserRoutes(router *mux.Router) {
+	router.HandleFunc("/{id}/sessions", h.handleListSessions).Methods(http.MethodGet)
+	router.HandleFunc("/{id}/sessions", h.handleRevokeSessions).Methods(http.MethodDelete)
+	router.HandleFunc("/{id}/sessions/{sessionId}", h.handleRevokeSession).Methods(http.MethodDelete)
+}
+
+func (h *SessionHandler) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateSessionPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	user, err := h.users.GetUserById(r.Context(), payload.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
+		return
+	}
+
+	token, hash, err := service.NewRefreshToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	session := types.Session{
+		UserID:    user.ID,
+		Device:    payload.Device,
+		IP:        payload.IP,
+		UserAgent: truncate(payload.UserAgent, 255),
+		ExpiresAt: sessionExpiry(),
+	}
+
+	id, err := h.store.CreateSession(r.Context(), session, hash)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	created, err := h.store.GetSession(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.IssuedSession{
+		Session:      *created,
+		User:         *user,
+		RefreshToken: token,
+	})
+}
+
+// handleRefreshSession trades a refresh token for the next one. Reusing a
+// token revokes its session, as someone else may have a copy.
+func (h *SessionHandler) handleRefreshSession(w http.ResponseWriter, r *http.Request) {
+	var payload types.RefreshSessionPayload
+	if err := utils.ReqParseJSON(r, &payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteValidationError(w, r, err)
+		return
+	}
+
+	token, hash, err := service.NewRefreshToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	session, err := h.store.RefreshSession(r.Context(), service.HashRefreshToken(payload.RefreshToken), hash, types.Session{
+		IP:        payload.IP,
+		UserAgent: truncate(payload.UserAgent, 255),
+		ExpiresAt: sessionExpiry(),
+	})
+	if errors.Is(err, types.ErrRefreshTokenReused) {
+		logger.FromContext(r.Context()).Warn("refresh token reused, session revoked", "ip", payload.IP)
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if errors.Is(err, types.ErrInvalidRefreshToken) {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err := h.users.GetUserById(r.Context(), session.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("failed to get user by id: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.IssuedSession{
+		Session:      *session,
+		User:         *user,
+		RefreshToken: token,
+	})
+}
+
+// handleCheckSession answers whether the session is active. Sessions that
+// do not exist are not.
+func (h *SessionHandler) handleCheckSession(w http.ResponseWriter, r *http.Request) {
+	sessionId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid session id"))
+		return
+	}
+
+	session, err := h.store.GetSession(r.Context(), sessionId)
+	if err != nil && !errors.Is(err, types.ErrSessionNotFound) {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.SessionCheck{
+		ID:     sessionId,
+		Active: session != nil && session.Active(),
+	})
+}
+
+func (h *SessionHandler) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.sessionOwner(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.store.ListSessions(r.Context(), userId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, sessions)
+}
+
+func (h *SessionHandler) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.sessionOwner(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, err := strconv.Atoi(mux.Vars(r)["sessionId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid session id"))
+		return
+	}
+
+	err = h.store.RevokeSession(r.Context(), userId, sessionId)
+	if errors.Is(err, types.ErrSessionNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": "Session revoked"})
+}
+
+func (h *SessionHandler) handleRevokeSessions(w http.ResponseWriter, r *http.Request) {
+	userId, ok := h.sessionOwner(w, r)
+	if !ok {
+		return
+	}
+
+	n, err := h.store.RevokeSessions(r.Context(), userId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"msg": fmt.Sprintf("%d sessions revoked", n)})
+}
+
+// sessionOwner returns the user whose sessions are requested, after
+// checking that the user exists and that the caller may manage them:
+// clients only manage their own.
+func (h *SessionHandler) sessionOwner(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id"))
+		return 0, false
+	}
+
+	if caller, restricted := identity.Restricted(r); restricted && caller.UserID != userId {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("cannot access the sessions of another user"))
+		return 0, false
+	}
+
+	if _, err := h.users.GetUserById(r.Context(), userId); err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("failed to get user by id: %v", err))
+		return 0, false
+	}
+
+	return userId, true
+}
+
+// sessionExpiry is when a session ends unless it is refreshed before.
+func sessionExpiry() time.Time {
+	return time.Now().Add(time.Duration(configs.Envs.Refresh_Token_TTL) * time.Second)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	// do not cut a character in half
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/4lerman/e_com/common/rpc"
+	userv1 "github.com/4lerman/e_com/proto/user/v1"
+	"github.com/4lerman/e_com/user/types"
+	"google.golang.org/grpc"
+)
+
+// Client reads and writes users through the user service's gRPC API, for
+// services that need users without sharing its database. Credentials are
+// not served over gRPC, so it has none of the password operations of
+// types.UserStore.
+type Client struct {
+	client userv1.UserStoreClient
+}
+
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{
+		client: userv1.NewUserStoreClient(conn),
+	}
+}
+
+func (c *Client) ListUsers(ctx context.Context) ([]types.User, error) {
+	list, err := c.client.ListUsers(ctx, &userv1.ListUsersRequest{})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromUserList(list), nil
+}
+
+func (c *Client) CreateUser(ctx context.Context, user types.User) (int, error) {
+	resp, err := c.client.CreateUser(ctx, &userv1.CreateUserRequest{User: toUser(user)})
+	if err != nil {
+		return 0, rpc.FromError(err)
+	}
+
+	return int(resp.GetId()), nil
+}
+
+func (c *Client) GetUserById(ctx context.Context, userId int) (*types.User, error) {
+	resp, err := c.client.GetUserById(ctx, &userv1.GetUserByIdRequest{Id: int32(userId)})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	user := fromUser(resp)
+	return &user, nil
+}
+
+func (c *Client) GetUsersByEmail(ctx context.Context, email string) ([]types.User, error) {
+	list, err := c.client.GetUsersByEmail(ctx, &userv1.GetUsersByEmailRequest{Email: email})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromUserList(list), nil
+}
+
+func (c *Client) GetUsersByName(ctx context.Context, name string) ([]types.User, error) {
+	list, err := c.client.GetUsersByName(ctx, &userv1.GetUsersByNameRequest{Name: name})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	return fromUserList(list), nil
+}
+
+func (c *Client) UpdateUser(ctx context.Context, userId int, user types.User) error {
+	_, err := c.client.UpdateUser(ctx, &userv1.UpdateUserRequest{Id: int32(userId), User: toUser(user)})
+	if err != nil {
+		return rpc.FromError(err)
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteUser(ctx context.Context, userId int) error {
+	_, err := c.client.DeleteUser(ctx, &userv1.DeleteUserRequest{Id: int32(userId)})
+	if err != nil {
+		return rpc.FromError(err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	resp, err := c.client.GetUserByEmail(ctx, &userv1.GetUserByEmailRequest{Email: email})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	user := fromUser(resp)
+	return &user, nil
+}
+
+func (c *Client) ListAddresses(ctx context.Context, userId int) ([]types.Address, error) {
+	list, err := c.client.ListAddresses(ctx, &userv1.ListAddressesRequest{UserId: int32(userId)})
+	if err != nil {
+		return nil, rpc.FromError(err)
+	}
+
+	addresses := make([]types.Address, len(list.GetAddresses()))
+	for i, address := range list.GetAddresses() {
+		addresses[i] = fromAddress(address)
+	}
+
+	return addresses, nil
+}
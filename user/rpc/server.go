@@ -0,0 +1,195 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/4lerman/e_com/common/rpc"
+	userv1 "github.com/4lerman/e_com/proto/user/v1"
+	"github.com/4lerman/e_com/user/types"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server serves the user store over gRPC. Password hashes never leave the
+// user service, so users created through it have no password.
+type Server struct {
+	userv1.UnimplementedUserStoreServer
+	store types.UserStore
+}
+
+func NewServer(store types.UserStore) *Server {
+	return &Server{
+		store: store,
+	}
+}
+
+// storeError serves users and addresses that do not exist as NotFound.
+func storeError(err error) error {
+	return rpc.Error(err, types.ErrUserNotFound, types.ErrAddressNotFound)
+}
+
+func (s *Server) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.UserList, error) {
+	users, err := s.store.ListUsers(ctx)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toUserList(users), nil
+}
+
+func (s *Server) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.CreateUserResponse, error) {
+	user := fromUser(req.GetUser())
+	// only the owner of the address can verify it
+	user.EmailVerified = false
+
+	id, err := s.store.CreateUser(ctx, user)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return &userv1.CreateUserResponse{Id: int32(id)}, nil
+}
+
+func (s *Server) GetUserById(ctx context.Context, req *userv1.GetUserByIdRequest) (*userv1.User, error) {
+	user, err := s.store.GetUserById(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toUser(*user), nil
+}
+
+func (s *Server) GetUsersByEmail(ctx context.Context, req *userv1.GetUsersByEmailRequest) (*userv1.UserList, error) {
+	users, err := s.store.GetUsersByEmail(ctx, req.GetEmail())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toUserList(users), nil
+}
+
+func (s *Server) GetUsersByName(ctx context.Context, req *userv1.GetUsersByNameRequest) (*userv1.UserList, error) {
+	users, err := s.store.GetUsersByName(ctx, req.GetName())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toUserList(users), nil
+}
+
+func (s *Server) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*emptypb.Empty, error) {
+	if err := s.store.UpdateUser(ctx, int(req.GetId()), fromUser(req.GetUser())); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteUser erases the personal data of the user, like deleting a user
+// over HTTP does. It is logged without a requester.
+func (s *Server) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := s.store.EraseUser(ctx, types.DataRequest{UserID: int(req.GetId()), Kind: types.Erasure}); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetUserByEmail(ctx context.Context, req *userv1.GetUserByEmailRequest) (*userv1.User, error) {
+	user, err := s.store.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return toUser(*user), nil
+}
+
+func (s *Server) ListAddresses(ctx context.Context, req *userv1.ListAddressesRequest) (*userv1.AddressList, error) {
+	addresses, err := s.store.ListAddresses(ctx, int(req.GetUserId()))
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	list := &userv1.AddressList{Addresses: make([]*userv1.Address, len(addresses))}
+	for i, address := range addresses {
+		list.Addresses[i] = toAddress(address)
+	}
+
+	return list, nil
+}
+
+func toUser(user types.User) *userv1.User {
+	return &userv1.User{
+		Id:            int32(user.ID),
+		FullName:      user.FullName,
+		Address:       user.Address,
+		Email:         user.Email,
+		RegisterDate:  timestamppb.New(user.RegisterDate),
+		UserRole:      string(user.UserRole),
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+func fromUser(user *userv1.User) types.User {
+	return types.User{
+		ID:            int(user.GetId()),
+		FullName:      user.GetFullName(),
+		Address:       user.GetAddress(),
+		Email:         user.GetEmail(),
+		RegisterDate:  user.GetRegisterDate().AsTime(),
+		UserRole:      types.UserRole(user.GetUserRole()),
+		EmailVerified: user.GetEmailVerified(),
+	}
+}
+
+func fromUserList(list *userv1.UserList) []types.User {
+	users := make([]types.User, len(list.GetUsers()))
+	for i, user := range list.GetUsers() {
+		users[i] = fromUser(user)
+	}
+
+	return users
+}
+
+func toUserList(users []types.User) *userv1.UserList {
+	list := &userv1.UserList{Users: make([]*userv1.User, len(users))}
+	for i, user := range users {
+		list.Users[i] = toUser(user)
+	}
+
+	return list
+}
+
+func toAddress(address types.Address) *userv1.Address {
+	return &userv1.Address{
+		Id:              int32(address.ID),
+		UserId:          int32(address.UserID),
+		Label:           address.Label,
+		Country:         address.Country,
+		City:            address.City,
+		Street:          address.Street,
+		PostalCode:      address.PostalCode,
+		Phone:           address.Phone,
+		DefaultShipping: address.DefaultShipping,
+		DefaultBilling:  address.DefaultBilling,
+		CreatedAt:       timestamppb.New(address.CreatedAt),
+		UpdatedAt:       timestamppb.New(address.UpdatedAt),
+	}
+}
+
+func fromAddress(address *userv1.Address) types.Address {
+	return types.Address{
+		ID:              int(address.GetId()),
+		UserID:          int(address.GetUserId()),
+		Label:           address.GetLabel(),
+		Country:         address.GetCountry(),
+		City:            address.GetCity(),
+		Street:          address.GetStreet(),
+		PostalCode:      address.GetPostalCode(),
+		Phone:           address.GetPhone(),
+		DefaultShipping: address.GetDefaultShipping(),
+		DefaultBilling:  address.GetDefaultBilling(),
+		CreatedAt:       address.GetCreatedAt().AsTime(),
+		UpdatedAt:       address.GetUpdatedAt().AsTime(),
+	}
+}
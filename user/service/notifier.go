@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/common/mail"
+	"github.com/4lerman/e_com/user/types"
+)
+
+// Notifier delivers account messages to users.
+type Notifier interface {
+	SendVerification(ctx context.Context, user types.User, token string) error
+	SendPasswordReset(ctx context.Context, user types.User, token string) error
+}
+
+// MailNotifier emails the messages through a mailer.
+type MailNotifier struct {
+	mailer mail.Mailer
+}
+
+func NewMailNotifier(mailer mail.Mailer) *MailNotifier {
+	return &MailNotifier{
+		mailer: mailer,
+	}
+}
+
+func (n *MailNotifier) SendVerification(ctx context.Context, user types.User, token string) error {
+	return n.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nplease confirm your email address to start placing orders:\n\n%s\n\n"+
+			"If you did not create an account, you can ignore this email.\n",
+			user.FullName, link("verify-email", token)),
+	})
+}
+
+func (n *MailNotifier) SendPasswordReset(ctx context.Context, user types.User, token string) error {
+	return n.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nuse this to choose a new password:\n\n%s\n\n"+
+			"If you did not ask for a password reset, you can ignore this email.\n",
+			user.FullName, link("reset-password", token)),
+	})
+}
+
+// link points to the frontend page that takes the token, or is the token
+// itself when there is no frontend.
+func link(page, token string) string {
+	if configs.Envs.Frontend_Url == "" {
+		return token
+	}
+
+	return strings.TrimSuffix(configs.Envs.Frontend_Url, "/") + "/" + page + "?token=" + url.QueryEscape(token)
+}
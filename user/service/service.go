@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	configs "github.com/4lerman/e_com/common/config"
+	"github.com/4lerman/e_com/user/types"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+// dummyHash is compared against when there is no hash to check, so failed
+// logins take as long whether the account exists or not.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewResetToken returns a password reset token for the user and the hash
+// to store; only the hash is kept.
+func NewResetToken() (token, hash string, err error) {
+	return newToken()
+}
+
+func HashResetToken(token string) string {
+	return hashToken(token)
+}
+
+// NewRefreshToken returns a refresh token for a session and the hash to
+// store; only the hash is kept.
+func NewRefreshToken() (token, hash string, err error) {
+	return newToken()
+}
+
+func HashRefreshToken(token string) string {
+	return hashToken(token)
+}
+
+func newToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token = hex.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewVerificationToken returns a token proving that whoever holds it can
+// read the user's email, valid until expires. It is signed instead of
+// stored, and stops working if the address changes.
+func NewVerificationToken(user types.User, expires time.Time) string {
+	claims := fmt.Sprintf("%d.%d", user.ID, expires.Unix())
+	return claims + "." + signVerification(claims, user.Email)
+}
+
+// VerifyEmail marks the email of the user the token was issued for as
+// verified. Verifying an address twice is not an error.
+func VerifyEmail(ctx context.Context, store types.UserStore, token string) (*types.User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, types.ErrInvalidVerificationToken
+	}
+
+	userId, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, types.ErrInvalidVerificationToken
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return nil, types.ErrInvalidVerificationToken
+	}
+
+	user, err := store.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, types.ErrInvalidVerificationToken
+	}
+
+	claims := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(signVerification(claims, user.Email))) {
+		return nil, types.ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	if err := store.SetEmailVerified(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	user.EmailVerified = true
+	return user, nil
+}
+
+// signVerification signs the claims of a verification token for email. The
+// key is derived from JWT_SECRET, so neither kind of token passes for the
+// other.
+func signVerification(claims, email string) string {
+	key := hmac.New(sha256.New, []byte(configs.Envs.JWT_Secret))
+	key.Write([]byte("email verification"))
+
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(claims + "." + email))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// EnsureAdmin creates the bootstrap admin account, or resets its password if
+// it already exists, so there is always someone who can log in.
+func EnsureAdmin(ctx context.Context, store types.UserStore, email, password string) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user, err := store.GetUserByEmail(ctx, email)
+	if err != nil {
+		_, err := store.CreateUser(ctx, types.User{
+			FullName:     "Administrator",
+			Address:      "-",
+			Email:        email,
+			UserRole:     types.Admin,
+			PasswordHash: hash,
+			// the operator chose the address
+			EmailVerified: true,
+		})
+		return err
+	}
+
+	if user.UserRole != types.Admin {
+		return fmt.Errorf("bootstrap admin %s exists with role %s", email, user.UserRole)
+	}
+
+	return store.UpdatePassword(ctx, user.ID, hash)
+}
@@ -0,0 +1,247 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/4lerman/e_com/common/tracing"
+	"github.com/4lerman/e_com/user/types"
+)
+
+const addressColumns = "id, userId, label, country, city, street, postalCode, phone, defaultShipping, defaultBilling, createdAt, updatedAt"
+
+func (s *Store) ListAddresses(ctx context.Context, userId int) ([]types.Address, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.ListAddresses")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+addressColumns+" FROM user_addresses WHERE userId = $1 ORDER BY id", userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	addresses := []types.Address{}
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, *address)
+	}
+
+	return addresses, rows.Err()
+}
+
+func (s *Store) GetAddress(ctx context.Context, userId int, addressId int) (*types.Address, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetAddress")
+	defer span.End()
+
+	address, err := scanAddress(s.db.QueryRowContext(ctx, "SELECT "+addressColumns+" FROM user_addresses "+
+		"WHERE id = $1 AND userId = $2", addressId, userId))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, types.ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+// CreateAddress adds the address to the user's book. The first address of
+// a user becomes both defaults.
+func (s *Store) CreateAddress(ctx context.Context, address types.Address) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.CreateAddress")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create address: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockAddresses(ctx, tx, address.UserID); err != nil {
+		return 0, err
+	}
+
+	var first bool
+	err = tx.QueryRowContext(ctx, "SELECT NOT EXISTS (SELECT 1 FROM user_addresses WHERE userId = $1)", address.UserID).Scan(&first)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	if first {
+		address.DefaultShipping = true
+		address.DefaultBilling = true
+	}
+
+	if err := clearDefaults(ctx, tx, address); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, "INSERT INTO user_addresses "+
+		"(userId, label, country, city, street, postalCode, phone, defaultShipping, defaultBilling) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
+		address.UserID, address.Label, address.Country, address.City, address.Street, address.PostalCode, address.Phone,
+		address.DefaultShipping, address.DefaultBilling).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateAddress replaces the address. Default flags that are set move the
+// defaults to it; those that are not leave them where they are.
+func (s *Store) UpdateAddress(ctx context.Context, address types.Address) error {
+	ctx, span := tracing.Start(ctx, "UserStore.UpdateAddress")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update address: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockAddresses(ctx, tx, address.UserID); err != nil {
+		return err
+	}
+
+	if err := clearDefaults(ctx, tx, address); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "UPDATE user_addresses SET "+
+		"label = $1, country = $2, city = $3, street = $4, postalCode = $5, phone = $6, "+
+		"defaultShipping = defaultShipping OR $7, defaultBilling = defaultBilling OR $8, updatedAt = CURRENT_TIMESTAMP "+
+		"WHERE id = $9 AND userId = $10",
+		address.Label, address.Country, address.City, address.Street, address.PostalCode, address.Phone,
+		address.DefaultShipping, address.DefaultBilling, address.ID, address.UserID)
+
+	if err != nil {
+		return fmt.Errorf("failed to update address: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return types.ErrAddressNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update address: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAddress removes the address. The defaults it held pass to the
+// user's oldest remaining address.
+func (s *Store) DeleteAddress(ctx context.Context, userId int, addressId int) error {
+	ctx, span := tracing.Start(ctx, "UserStore.DeleteAddress")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockAddresses(ctx, tx, userId); err != nil {
+		return err
+	}
+
+	var shipping, billing bool
+	err = tx.QueryRowContext(ctx, "DELETE FROM user_addresses WHERE id = $1 AND userId = $2 "+
+		"RETURNING defaultShipping, defaultBilling", addressId, userId).Scan(&shipping, &billing)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.ErrAddressNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+
+	if shipping || billing {
+		_, err = tx.ExecContext(ctx, "UPDATE user_addresses SET "+
+			"defaultShipping = defaultShipping OR $2, defaultBilling = defaultBilling OR $3 "+
+			"WHERE id = (SELECT MIN(id) FROM user_addresses WHERE userId = $1)", userId, shipping, billing)
+
+		if err != nil {
+			return fmt.Errorf("failed to delete address: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+
+	return nil
+}
+
+// lockAddresses serializes changes to the user's address book, so the
+// defaults cannot be lost or doubled by concurrent writes. NO KEY UPDATE
+// does not hold up inserts referencing the user.
+func lockAddresses(ctx context.Context, tx *sql.Tx, userId int) error {
+	var id int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE", userId).Scan(&id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.ErrUserNotFound
+	}
+
+	return err
+}
+
+// clearDefaults takes the defaults the address is about to get from the
+// user's other addresses.
+func clearDefaults(ctx context.Context, tx *sql.Tx, address types.Address) error {
+	if !address.DefaultShipping && !address.DefaultBilling {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, "UPDATE user_addresses SET "+
+		"defaultShipping = defaultShipping AND NOT $1, defaultBilling = defaultBilling AND NOT $2 "+
+		"WHERE userId = $3 AND id <> $4", address.DefaultShipping, address.DefaultBilling, address.UserID, address.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to move default address: %w", err)
+	}
+
+	return nil
+}
+
+func scanAddress(row interface{ Scan(...any) error }) (*types.Address, error) {
+	address := new(types.Address)
+
+	err := row.Scan(
+		&address.ID,
+		&address.UserID,
+		&address.Label,
+		&address.Country,
+		&address.City,
+		&address.Street,
+		&address.PostalCode,
+		&address.Phone,
+		&address.DefaultShipping,
+		&address.DefaultBilling,
+		&address.CreatedAt,
+		&address.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/4lerman/e_com/common/tracing"
+	"github.com/4lerman/e_com/user/types"
+)
+
+// EraseUser anonymises the personal data of the user and logs the request.
+// The account row stays, so the orders and payments referencing it are kept
+// as financial records; the address snapshots on orders are part of them.
+// Address book entries and pending password resets are deleted, and every
+// session is revoked.
+func (s *Store) EraseUser(ctx context.Context, request types.DataRequest) error {
+	ctx, span := tracing.Start(ctx, "UserStore.EraseUser")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to erase user: %w", err)
+	}
+	defer tx.Rollback()
+
+	var erasedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT erasedAt FROM users WHERE id = $1 FOR UPDATE", request.UserID).Scan(&erasedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to erase user: %w", err)
+	}
+	if erasedAt.Valid {
+		return types.ErrUserErased
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET "+
+		"fullName = 'Erased user', email = 'erased-' || id || '@invalid', address = '', "+
+		"passwordHash = NULL, emailVerified = FALSE, erasedAt = CURRENT_TIMESTAMP WHERE id = $1", request.UserID)
+
+	if err != nil {
+		return fmt.Errorf("failed to erase user: %w", err)
+	}
+
+	for _, table := range []string{"user_addresses", "password_resets"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE userId = $1", request.UserID); err != nil {
+			return fmt.Errorf("failed to erase user: %w", err)
+		}
+	}
+
+	if _, err := revokeSessions(ctx, tx, "account erased", "userId = $3", request.UserID); err != nil {
+		return err
+	}
+
+	request.Kind = types.Erasure
+	if err := logDataRequest(ctx, tx, request); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to erase user: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) LogDataRequest(ctx context.Context, request types.DataRequest) error {
+	ctx, span := tracing.Start(ctx, "UserStore.LogDataRequest")
+	defer span.End()
+
+	return logDataRequest(ctx, s.db, request)
+}
+
+func (s *Store) ListDataRequests(ctx context.Context, userId int) ([]types.DataRequest, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.ListDataRequests")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, userId, kind, requestedBy, requestedByRole, createdAt "+
+		"FROM data_requests WHERE userId = $1 ORDER BY id", userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	requests := []types.DataRequest{}
+	for rows.Next() {
+		var request types.DataRequest
+		var requestedBy sql.NullInt64
+		var requestedByRole sql.NullString
+
+		err := rows.Scan(&request.ID, &request.UserID, &request.Kind, &requestedBy, &requestedByRole, &request.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if requestedBy.Valid {
+			id := int(requestedBy.Int64)
+			request.RequestedBy = &id
+		}
+		request.RequestedByRole = requestedByRole.String
+
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+func logDataRequest(ctx context.Context, db interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, request types.DataRequest) error {
+	var requestedBy sql.NullInt64
+	if request.RequestedBy != nil {
+		requestedBy = sql.NullInt64{Int64: int64(*request.RequestedBy), Valid: true}
+	}
+
+	_, err := db.ExecContext(ctx, "INSERT INTO data_requests (userId, kind, requestedBy, requestedByRole) "+
+		"VALUES ($1, $2, $3, $4)", request.UserID, request.Kind, requestedBy, nullString(request.RequestedByRole))
+
+	if err != nil {
+		return fmt.Errorf("failed to log data request: %w", err)
+	}
+
+	return nil
+}
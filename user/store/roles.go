@@ -0,0 +1,311 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/4lerman/e_com/common/tracing"
+	"github.com/4lerman/e_com/user/types"
+	"github.com/lib/pq"
+)
+
+// roleQuery selects roles with the names of their permissions.
+const roleQuery = "SELECT roles.id, roles.name, roles.description, roles.builtIn, roles.createdAt, " +
+	"COALESCE(array_agg(permissions.name ORDER BY permissions.name) FILTER (WHERE permissions.name IS NOT NULL), '{}') " +
+	"FROM roles LEFT JOIN role_permissions ON role_permissions.roleId = roles.id " +
+	"LEFT JOIN permissions ON permissions.id = role_permissions.permissionId "
+
+func (s *Store) ListRoles(ctx context.Context) ([]types.Role, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.ListRoles")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, roleQuery+"GROUP BY roles.id ORDER BY roles.id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	roles := []types.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, *role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (s *Store) GetRole(ctx context.Context, name string) (*types.Role, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetRole")
+	defer span.End()
+
+	role, err := scanRole(s.db.QueryRowContext(ctx, roleQuery+"WHERE roles.name = $1 GROUP BY roles.id", name))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, types.ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *Store) CreateRole(ctx context.Context, role types.Role) error {
+	ctx, span := tracing.Start(ctx, "UserStore.CreateRole")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, "INSERT INTO roles (name, description) VALUES ($1, $2) "+
+		"ON CONFLICT (name) DO NOTHING RETURNING id", role.Name, role.Description).Scan(&id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.ErrRoleExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	if err := grant(ctx, tx, id, role.Permissions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRole replaces the description and the permissions of the role.
+func (s *Store) UpdateRole(ctx context.Context, role types.Role) error {
+	ctx, span := tracing.Start(ctx, "UserStore.UpdateRole")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, "UPDATE roles SET description = $1 WHERE name = $2 RETURNING id", role.Description, role.Name).Scan(&id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.ErrRoleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE roleId = $1", id); err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	if err := grant(ctx, tx, id, role.Permissions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRole deletes a role nobody has.
+func (s *Store) DeleteRole(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "UserStore.DeleteRole")
+	defer span.End()
+
+	var inUse bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE userRole = $1)", name).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	if inUse {
+		return types.ErrRoleInUse
+	}
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM roles WHERE name = $1", name)
+
+	// a user may have been given the role in the meantime
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+		return types.ErrRoleInUse
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return types.ErrRoleNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) AddPermission(ctx context.Context, role string, permission string) error {
+	ctx, span := tracing.Start(ctx, "UserStore.AddPermission")
+	defer span.End()
+
+	if err := checkPermissions(ctx, s.db, []string{permission}); err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, "INSERT INTO role_permissions (roleId, permissionId) "+
+		"SELECT roles.id, permissions.id FROM roles, permissions WHERE roles.name = $1 AND permissions.name = $2 "+
+		"ON CONFLICT DO NOTHING", role, permission)
+
+	if err != nil {
+		return fmt.Errorf("failed to add permission: %w", err)
+	}
+
+	// nothing is inserted for a missing role or a permission held already
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		if _, err := s.GetRole(ctx, role); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) RemovePermission(ctx context.Context, role string, permission string) error {
+	ctx, span := tracing.Start(ctx, "UserStore.RemovePermission")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM role_permissions USING roles, permissions "+
+		"WHERE role_permissions.roleId = roles.id AND role_permissions.permissionId = permissions.id "+
+		"AND roles.name = $1 AND permissions.name = $2", role, permission)
+
+	if err != nil {
+		return fmt.Errorf("failed to remove permission: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) ListPermissions(ctx context.Context) ([]types.Permission, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.ListPermissions")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT name, description FROM permissions ORDER BY id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	permissions := []types.Permission{}
+	for rows.Next() {
+		var permission types.Permission
+		if err := rows.Scan(&permission.Name, &permission.Description); err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+func (s *Store) HasPermission(ctx context.Context, role string, permission string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.HasPermission")
+	defer span.End()
+
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM role_permissions "+
+		"JOIN roles ON roles.id = role_permissions.roleId JOIN permissions ON permissions.id = role_permissions.permissionId "+
+		"WHERE roles.name = $1 AND permissions.name = $2)", role, permission).Scan(&allowed)
+
+	if err != nil {
+		return false, err
+	}
+
+	return allowed, nil
+}
+
+// grant gives the role the permissions, which must all be in the catalog.
+func grant(ctx context.Context, tx *sql.Tx, roleId int, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	if err := checkPermissions(ctx, tx, permissions); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO role_permissions (roleId, permissionId) "+
+		"SELECT $1, id FROM permissions WHERE name = ANY($2) ON CONFLICT DO NOTHING", roleId, pq.Array(permissions))
+
+	if err != nil {
+		return fmt.Errorf("failed to grant permissions: %w", err)
+	}
+
+	return nil
+}
+
+// checkPermissions returns ErrPermissionNotFound for the first of the
+// permissions that is not in the catalog.
+func checkPermissions(ctx context.Context, db querier, permissions []string) error {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM permissions WHERE name = ANY($1)", pq.Array(permissions))
+	if err != nil {
+		return fmt.Errorf("failed to check permissions: %w", err)
+	}
+
+	defer rows.Close()
+
+	known := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		known[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, permission := range permissions {
+		if !known[permission] {
+			return fmt.Errorf("%w: %s", types.ErrPermissionNotFound, permission)
+		}
+	}
+
+	return nil
+}
+
+func scanRole(row interface{ Scan(...any) error }) (*types.Role, error) {
+	role := new(types.Role)
+
+	err := row.Scan(
+		&role.ID,
+		&role.Name,
+		&role.Description,
+		&role.BuiltIn,
+		&role.CreatedAt,
+		pq.Array(&role.Permissions),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
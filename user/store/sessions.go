@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/4lerman/e_com/common/events"
+	"github.com/4lerman/e_com/common/tracing"
+	"github.com/4lerman/e_com/user/types"
+)
+
+const sessionColumns = "id, userId, device, ip, userAgent, createdAt, lastSeenAt, expiresAt, revokedAt"
+
+// querier is a *sql.DB or *sql.Tx.
+type querier interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+// CreateSession opens the session with its first refresh token.
+func (s *Store) CreateSession(ctx context.Context, session types.Session, tokenHash string) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.CreateSession")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, "INSERT INTO sessions (userId, device, ip, userAgent, expiresAt) "+
+		"VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		session.UserID, session.Device, session.IP, session.UserAgent, session.ExpiresAt).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO refresh_tokens (sessionId, tokenHash) VALUES ($1, $2)", id, tokenHash); err != nil {
+		return 0, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return id, nil
+}
+
+// RefreshSession uses up the refresh token with the given hash and gives
+// its session the next one. The session is seen again from where seen says
+// and lasts until seen.ExpiresAt. A token used before revokes its session.
+func (s *Store) RefreshSession(ctx context.Context, tokenHash string, nextTokenHash string, seen types.Session) (*types.Session, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.RefreshSession")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tokenId, sessionId int
+	var usedAt sql.NullTime
+	var active bool
+	err = tx.QueryRowContext(ctx, "SELECT refresh_tokens.id, refresh_tokens.usedAt, sessions.id, "+
+		"sessions.revokedAt IS NULL AND sessions.expiresAt > CURRENT_TIMESTAMP "+
+		"FROM refresh_tokens JOIN sessions ON sessions.id = refresh_tokens.sessionId "+
+		"WHERE refresh_tokens.tokenHash = $1 FOR UPDATE", tokenHash).Scan(&tokenId, &usedAt, &sessionId, &active)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, types.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+	if !active {
+		return nil, types.ErrInvalidRefreshToken
+	}
+
+	if usedAt.Valid {
+		if _, err := revokeSessions(ctx, tx, "refresh token reused", "id = $3", sessionId); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to refresh session: %w", err)
+		}
+
+		return nil, types.ErrRefreshTokenReused
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET usedAt = CURRENT_TIMESTAMP WHERE id = $1", tokenId); err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO refresh_tokens (sessionId, tokenHash) VALUES ($1, $2)", sessionId, nextTokenHash); err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	session, err := scanSession(tx.QueryRowContext(ctx, "UPDATE sessions SET "+
+		"ip = $1, userAgent = $2, expiresAt = $3, lastSeenAt = CURRENT_TIMESTAMP WHERE id = $4 RETURNING "+sessionColumns,
+		seen.IP, seen.UserAgent, seen.ExpiresAt, sessionId))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (s *Store) GetSession(ctx context.Context, sessionId int) (*types.Session, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetSession")
+	defer span.End()
+
+	session, err := scanSession(s.db.QueryRowContext(ctx, "SELECT "+sessionColumns+" FROM sessions WHERE id = $1", sessionId))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, types.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ListSessions returns the active sessions of the user, most recently seen
+// first.
+func (s *Store) ListSessions(ctx context.Context, userId int) ([]types.Session, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.ListSessions")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+sessionColumns+" FROM sessions "+
+		"WHERE userId = $1 AND revokedAt IS NULL AND expiresAt > CURRENT_TIMESTAMP ORDER BY lastSeenAt DESC", userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := []types.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (s *Store) RevokeSession(ctx context.Context, userId int, sessionId int) error {
+	ctx, span := tracing.Start(ctx, "UserStore.RevokeSession")
+	defer span.End()
+
+	n, err := revokeSessions(ctx, s.db, "revoked", "id = $3 AND userId = $4", sessionId, userId)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return types.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeSessions revokes every session of the user and returns how many
+// there were.
+func (s *Store) RevokeSessions(ctx context.Context, userId int) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.RevokeSessions")
+	defer span.End()
+
+	return revokeSessions(ctx, s.db, "revoked", "userId = $3", userId)
+}
+
+// revokeSessions ends the sessions matching condition, whose parameters
+// start at $3, and tells the gateway once the change is committed. It
+// returns how many sessions it ended.
+func revokeSessions(ctx context.Context, q querier, reason string, condition string, args ...any) (int, error) {
+	rows, err := q.QueryContext(ctx, "WITH revoked AS ("+
+		"UPDATE sessions SET revokedAt = CURRENT_TIMESTAMP, revokedReason = $1 "+
+		"WHERE revokedAt IS NULL AND "+condition+" RETURNING id"+
+		") SELECT pg_notify($2, id::text) FROM revoked", append([]any{reason, events.SessionsChannel}, args...)...)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		n++
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return n, nil
+}
+
+func scanSession(row interface{ Scan(...any) error }) (*types.Session, error) {
+	session := new(types.Session)
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Device,
+		&session.IP,
+		&session.UserAgent,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&revokedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, nil
+}
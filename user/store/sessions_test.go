@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/4lerman/e_com/common/db/dbtest"
+	"github.com/4lerman/e_com/user/types"
+)
+
+// testHash returns a refresh token hash unique to the test run.
+func testHash(token string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", token, time.Now().UnixNano())))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRefreshSessionReuse(t *testing.T) {
+	s := NewStore(dbtest.Open(t))
+	ctx := context.Background()
+
+	userId, err := s.CreateUser(ctx, types.User{
+		FullName: "Session Test",
+		Email:    fmt.Sprintf("session-test-%d@example.com", time.Now().UnixNano()),
+		UserRole: types.Client,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	first, second, third, fourth := testHash("first"), testHash("second"), testHash("third"), testHash("fourth")
+
+	seen := types.Session{UserID: userId, ExpiresAt: time.Now().Add(time.Hour)}
+	sessionId, err := s.CreateSession(ctx, seen, first)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	if _, err := s.RefreshSession(ctx, first, second, seen); err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+
+	if _, err := s.RefreshSession(ctx, first, third, seen); !errors.Is(err, types.ErrRefreshTokenReused) {
+		t.Fatalf("RefreshSession() with a used token error = %v, want %v", err, types.ErrRefreshTokenReused)
+	}
+
+	session, err := s.GetSession(ctx, sessionId)
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	if session.Active() {
+		t.Errorf("session is active after its refresh token was reused")
+	}
+
+	if _, err := s.RefreshSession(ctx, second, fourth, seen); !errors.Is(err, types.ErrInvalidRefreshToken) {
+		t.Errorf("RefreshSession() on the revoked session error = %v, want %v", err, types.ErrInvalidRefreshToken)
+	}
+}
@@ -1,12 +1,18 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/4lerman/e_com/common/tracing"
 	"github.com/4lerman/e_com/user/types"
 )
 
+const userColumns = "id, fullName, email, address, registerDate, userRole, passwordHash, emailVerified, erasedAt"
+
 type Store struct {
 	db *sql.DB
 }
@@ -17,8 +23,11 @@ func NewStore(db *sql.DB) *Store {
 	}
 }
 
-func (s *Store) ListUsers() ([]types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users")
+func (s *Store) ListUsers(ctx context.Context) ([]types.User, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.ListUsers")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users")
 
 	if err != nil {
 		return nil, err
@@ -39,19 +48,26 @@ func (s *Store) ListUsers() ([]types.User, error) {
 	return users, nil
 }
 
-func (s *Store) CreateUser(user types.User) error {
-	_, err := s.db.Exec("INSERT INTO users (fullName, address, email, userRole)"+
-		"VALUES ($1, $2, $3, $4)", user.FullName, user.Address, user.Email, user.UserRole)
+func (s *Store) CreateUser(ctx context.Context, user types.User) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.CreateUser")
+	defer span.End()
+
+	var id int
+	err := s.db.QueryRowContext(ctx, "INSERT INTO users (fullName, address, email, userRole, passwordHash, emailVerified)"+
+		"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id", user.FullName, user.Address, user.Email, user.UserRole, nullString(user.PasswordHash), user.EmailVerified).Scan(&id)
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return id, nil
 }
 
-func (s *Store) GetUserById(userId int) (*types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE id = $1", userId)
+func (s *Store) GetUserById(ctx context.Context, userId int) (*types.User, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetUserById")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", userId)
 
 	if err != nil {
 		return nil, err
@@ -68,19 +84,48 @@ func (s *Store) GetUserById(userId int) (*types.User, error) {
 	}
 
 	if user.ID == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, types.ErrUserNotFound
 	}
 
 	return user, nil
 }
 
-func (s *Store) GetUsersByEmail(email string) ([]types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE email ILIKE $1", "%"+email+"%")
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetUserByEmail")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE email = $1", email)
 
 	if err != nil {
 		return nil, err
 	}
 
+	defer rows.Close()
+
+	user := new(types.User)
+	for rows.Next() {
+		user, err = ScanRowIntoUser(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if user.ID == 0 {
+		return nil, types.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (s *Store) GetUsersByEmail(ctx context.Context, email string) ([]types.User, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetUsersByEmail")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE email ILIKE $1", "%"+email+"%")
+
+	if err != nil {
+		return nil, err
+	}
 
 	users := []types.User{}
 	for rows.Next() {
@@ -95,14 +140,16 @@ func (s *Store) GetUsersByEmail(email string) ([]types.User, error) {
 	return users, nil
 }
 
-func (s *Store) GetUsersByName(name string) ([]types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE fullName ILIKE $1", "%"+name+"%")
+func (s *Store) GetUsersByName(ctx context.Context, name string) ([]types.User, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetUsersByName")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users WHERE fullName ILIKE $1", "%"+name+"%")
 
 	if err != nil {
 		return nil, err
 	}
 
-
 	users := []types.User{}
 	for rows.Next() {
 		user, err := ScanRowIntoUser(rows)
@@ -116,9 +163,12 @@ func (s *Store) GetUsersByName(name string) ([]types.User, error) {
 	return users, nil
 }
 
-func (s *Store) UpdateUser(userId int, user types.User) error {
-	_, err := s.db.Exec("UPDATE users SET "+
-		"fullName = $1, userRole = $2 WHERE id = $3", user.FullName, user.UserRole, userId)
+func (s *Store) UpdateUser(ctx context.Context, userId int, user types.User) error {
+	ctx, span := tracing.Start(ctx, "UserStore.UpdateUser")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET "+
+		"fullName = $1, address = $2, userRole = $3 WHERE id = $4", user.FullName, user.Address, user.UserRole, userId)
 
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
@@ -127,18 +177,121 @@ func (s *Store) UpdateUser(userId int, user types.User) error {
 	return nil
 }
 
-func (s *Store) DeleteUser(userId int) error {
-	_, err := s.db.Exec("DELETE FROM users WHERE id = $1", userId)
+func (s *Store) UpdatePassword(ctx context.Context, userId int, passwordHash string) error {
+	ctx, span := tracing.Start(ctx, "UserStore.UpdatePassword")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET passwordHash = $1 WHERE id = $2", passwordHash, userId)
+
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword sets the password of the user and revokes every session
+// of theirs but keepSession, the one the change was made in, if any.
+func (s *Store) ChangePassword(ctx context.Context, userId int, passwordHash string, keepSession int) error {
+	ctx, span := tracing.Start(ctx, "UserStore.ChangePassword")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET passwordHash = $1 WHERE id = $2", passwordHash, userId); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	if _, err := revokeSessions(ctx, tx, "password changed", "userId = $3 AND id <> $4", userId, keepSession); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) SetEmailVerified(ctx context.Context, userId int) error {
+	ctx, span := tracing.Start(ctx, "UserStore.SetEmailVerified")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET emailVerified = TRUE WHERE id = $1", userId)
+
+	if err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePasswordReset stores the hash of a reset token for the user that
+// is valid for ttl.
+func (s *Store) CreatePasswordReset(ctx context.Context, userId int, tokenHash string, ttl time.Duration) error {
+	ctx, span := tracing.Start(ctx, "UserStore.CreatePasswordReset")
+	defer span.End()
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO password_resets (userId, tokenHash, expiresAt) "+
+		"VALUES ($1, $2, CURRENT_TIMESTAMP + make_interval(secs => $3))", userId, tokenHash, int64(ttl.Seconds()))
 
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return fmt.Errorf("failed to create password reset: %w", err)
 	}
 
 	return nil
 }
 
+// ResetPassword uses up the reset token with the given hash and sets the
+// password of its user, who is returned. Every other pending reset of the
+// user is used up with it, and every session of the user is revoked.
+func (s *Store) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserStore.ResetPassword")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userId int
+	err = tx.QueryRowContext(ctx, "UPDATE password_resets SET usedAt = CURRENT_TIMESTAMP "+
+		"WHERE tokenHash = $1 AND usedAt IS NULL AND expiresAt > CURRENT_TIMESTAMP RETURNING userId", tokenHash).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, types.ErrInvalidResetToken
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET passwordHash = $1 WHERE id = $2", passwordHash, userId); err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE password_resets SET usedAt = CURRENT_TIMESTAMP WHERE userId = $1 AND usedAt IS NULL", userId); err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if _, err := revokeSessions(ctx, tx, "password reset", "userId = $3", userId); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	return userId, nil
+}
+
 func ScanRowIntoUser(rows *sql.Rows) (*types.User, error) {
 	user := new(types.User)
+	var passwordHash sql.NullString
+	var erasedAt sql.NullTime
 
 	err := rows.Scan(
 		&user.ID,
@@ -147,11 +300,23 @@ func ScanRowIntoUser(rows *sql.Rows) (*types.User, error) {
 		&user.Address,
 		&user.RegisterDate,
 		&user.UserRole,
+		&passwordHash,
+		&user.EmailVerified,
+		&erasedAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	user.PasswordHash = passwordHash.String
+	if erasedAt.Valid {
+		user.ErasedAt = &erasedAt.Time
+	}
+
 	return user, nil
 }
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -1,42 +1,324 @@
 package types
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidResetToken is returned for password reset tokens that do not
+// exist, expired or were used.
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// ErrUserNotFound is returned for users that do not exist.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrAddressNotFound is returned for addresses that do not exist or belong
+// to another user.
+var ErrAddressNotFound = errors.New("address not found")
+
+// ErrInvalidVerificationToken is returned for email verification tokens
+// that are malformed, expired or were issued for another address.
+var ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+
+// ErrRoleNotFound is returned for roles that do not exist.
+var ErrRoleNotFound = errors.New("role not found")
+
+// ErrRoleExists is returned when creating a role under a name that is
+// taken.
+var ErrRoleExists = errors.New("role already exists")
+
+// ErrRoleInUse is returned when deleting a role some users still have.
+var ErrRoleInUse = errors.New("role is assigned to users")
+
+// ErrPermissionNotFound is returned for permissions that are not in the
+// catalog.
+var ErrPermissionNotFound = errors.New("permission not found")
+
+// ErrSessionNotFound is returned for sessions that do not exist, belong to
+// another user or ended already.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrInvalidRefreshToken is returned for refresh tokens that do not exist
+// or whose session ended.
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// ErrRefreshTokenReused is returned for refresh tokens that were used
+// already. Someone else may hold a copy, so their session is revoked.
+var ErrRefreshTokenReused = errors.New("refresh token was used already, the session is revoked")
+
+// ErrUserErased is returned when erasing a user whose personal data is
+// erased already.
+var ErrUserErased = errors.New("user data is erased already")
 
 type UserStore interface {
-	ListUsers() ([]User, error)
-	CreateUser(User) error
-	GetUserById(int) (*User, error)
-	GetUsersByEmail(string) ([]User, error)
-	GetUsersByName(string) ([]User, error)
-	UpdateUser(int, User) error
-	DeleteUser(int) error
+	ListUsers(context.Context) ([]User, error)
+	CreateUser(context.Context, User) (int, error)
+	GetUserById(context.Context, int) (*User, error)
+	GetUsersByEmail(context.Context, string) ([]User, error)
+	GetUsersByName(context.Context, string) ([]User, error)
+	UpdateUser(context.Context, int, User) error
+	EraseUser(context.Context, DataRequest) error
+	GetUserByEmail(context.Context, string) (*User, error)
+	UpdatePassword(context.Context, int, string) error
+	ChangePassword(context.Context, int, string, int) error
+	SetEmailVerified(context.Context, int) error
+	CreatePasswordReset(context.Context, int, string, time.Duration) error
+	ResetPassword(context.Context, string, string) (int, error)
+	ListAddresses(context.Context, int) ([]Address, error)
+	GetAddress(context.Context, int, int) (*Address, error)
+	CreateAddress(context.Context, Address) (int, error)
+	UpdateAddress(context.Context, Address) error
+	DeleteAddress(context.Context, int, int) error
+	LogDataRequest(context.Context, DataRequest) error
+	ListDataRequests(context.Context, int) ([]DataRequest, error)
+}
+
+// RoleStore keeps the roles users can have and the permissions they hold.
+type RoleStore interface {
+	ListRoles(context.Context) ([]Role, error)
+	GetRole(context.Context, string) (*Role, error)
+	CreateRole(context.Context, Role) error
+	UpdateRole(context.Context, Role) error
+	DeleteRole(context.Context, string) error
+	AddPermission(context.Context, string, string) error
+	RemovePermission(context.Context, string, string) error
+	ListPermissions(context.Context) ([]Permission, error)
+	HasPermission(context.Context, string, string) (bool, error)
+}
+
+// SessionStore keeps the sessions users log in with. Each session is
+// continued with a chain of single-use refresh tokens.
+type SessionStore interface {
+	CreateSession(context.Context, Session, string) (int, error)
+	RefreshSession(context.Context, string, string, Session) (*Session, error)
+	GetSession(context.Context, int) (*Session, error)
+	ListSessions(context.Context, int) ([]Session, error)
+	RevokeSession(context.Context, int, int) error
+	RevokeSessions(context.Context, int) (int, error)
 }
 
+// UserRole names a row of the roles table.
 type UserRole string
 
+// The built-in roles. Admins may do everything; clients are customers, who
+// manage their own account, orders and payments. Every other role is a
+// staff role, which may do what its permissions allow on every record.
 const (
 	Admin  UserRole = "admin"
 	Client UserRole = "client"
 )
 
 type User struct {
-	ID           int       `json:"id"`
-	FullName     string    `json:"full_name"`
-	Address      string    `json:"address"`
-	Email        string    `json:"email"`
-	RegisterDate time.Time `json:"register_date"`
-	UserRole     UserRole  `json:"user_role"`
+	ID            int        `json:"id"`
+	FullName      string     `json:"full_name"`
+	Address       string     `json:"address"`
+	Email         string     `json:"email"`
+	RegisterDate  time.Time  `json:"register_date"`
+	UserRole      UserRole   `json:"user_role"`
+	EmailVerified bool       `json:"email_verified"`
+	ErasedAt      *time.Time `json:"erased_at,omitempty"`
+	PasswordHash  string     `json:"-"`
+}
+
+// Address is an entry of a user's address book. A user with addresses has
+// exactly one default shipping and one default billing address, which may
+// be the same.
+type Address struct {
+	ID              int       `json:"id"`
+	UserID          int       `json:"user_id"`
+	Label           string    `json:"label"`
+	Country         string    `json:"country"`
+	City            string    `json:"city"`
+	Street          string    `json:"street"`
+	PostalCode      string    `json:"postal_code"`
+	Phone           string    `json:"phone"`
+	DefaultShipping bool      `json:"default_shipping"`
+	DefaultBilling  bool      `json:"default_billing"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Role is a set of permissions users can be given. Built-in roles cannot be
+// changed or deleted.
+type Role struct {
+	ID          int       `json:"id"`
+	Name        UserRole  `json:"name"`
+	Description string    `json:"description"`
+	BuiltIn     bool      `json:"built_in"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Permission allows an action on a resource, named resource:action, such
+// as product:update.
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type CreateRolePayload struct {
+	Name        UserRole `json:"name" validate:"required,max=50,lowercase,excludesall=/:?#"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,required"`
+}
+
+// UpdateRolePayload replaces the description and permissions of a role.
+type UpdateRolePayload struct {
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,required"`
+}
+
+type AssignRolePayload struct {
+	Role UserRole `json:"role" validate:"required"`
+}
+
+// PermissionCheck is the answer to whether a role holds a permission.
+type PermissionCheck struct {
+	Role       UserRole `json:"role"`
+	Permission string   `json:"permission"`
+	Allowed    bool     `json:"allowed"`
+}
+
+// Session is a login of a user on a device. It lasts while it is refreshed
+// within REFRESH_TOKEN_TTL seconds and is not revoked. LastSeenAt is when it
+// last got tokens.
+type Session struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (s Session) Active() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// CreateSessionPayload opens a session for a user who just logged in. The
+// gateway sends it.
+type CreateSessionPayload struct {
+	UserID    int    `json:"user_id" validate:"required"`
+	Device    string `json:"device" validate:"omitempty,max=100"`
+	IP        string `json:"ip" validate:"omitempty,ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+// RefreshSessionPayload trades a refresh token for the next one. The
+// gateway sends it with where the request came from.
+type RefreshSessionPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+	IP           string `json:"ip" validate:"omitempty,ip"`
+	UserAgent    string `json:"user_agent"`
+}
+
+// IssuedSession is a session with its user and the refresh token that
+// continues it, which is never shown again.
+type IssuedSession struct {
+	Session      Session `json:"session"`
+	User         User    `json:"user"`
+	RefreshToken string  `json:"refresh_token"`
+}
+
+// SessionCheck is the answer to whether a session is active.
+type SessionCheck struct {
+	ID     int  `json:"id"`
+	Active bool `json:"active"`
+}
+
+type DataRequestKind string
+
+const (
+	Export  DataRequestKind = "export"
+	Erasure DataRequestKind = "erasure"
+)
+
+// DataRequest is an entry of the compliance log of exports and erasures of
+// personal data. Entries outlive the accounts they are about. RequestedBy
+// is nil for internal calls.
+type DataRequest struct {
+	ID              int             `json:"id"`
+	UserID          int             `json:"user_id"`
+	Kind            DataRequestKind `json:"kind"`
+	RequestedBy     *int            `json:"requested_by,omitempty"`
+	RequestedByRole string          `json:"requested_by_role,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// PersonalData is what the user service holds about a user, as exported
+// to them.
+type PersonalData struct {
+	Profile   User      `json:"profile"`
+	Addresses []Address `json:"addresses"`
+}
+
+// AddressPayload creates or replaces an address. Country is an ISO 3166-1
+// alpha-2 code and phone is in E.164 format. Setting a default flag moves
+// that default to the address; clearing it is done by making another
+// address the default.
+type AddressPayload struct {
+	Label           string `json:"label" validate:"omitempty,max=50"`
+	Country         string `json:"country" validate:"required,len=2,alpha"`
+	City            string `json:"city" validate:"required,max=100"`
+	Street          string `json:"street" validate:"required,max=255"`
+	PostalCode      string `json:"postal_code" validate:"required,max=20"`
+	Phone           string `json:"phone" validate:"omitempty,e164"`
+	DefaultShipping bool   `json:"default_shipping"`
+	DefaultBilling  bool   `json:"default_billing"`
 }
 
 type CreateUserPayload struct {
-	FullName string   `json:"full_name" validate:"required"`
-	Address  string   `json:"address" validate:"required"`
-	Email    string   `json:"email" validate:"required"`
+	FullName string   `json:"full_name" validate:"required,max=50"`
+	Address  string   `json:"address" validate:"required,max=255"`
+	Email    string   `json:"email" validate:"required,email,max=50"`
 	UserRole UserRole `json:"user_role" validate:"required"`
+	Password string   `json:"password" validate:"omitempty,min=8,max=72"`
+}
+
+// RegisterPayload is what people signing themselves up send; they always
+// get the client role.
+type RegisterPayload struct {
+	FullName string `json:"full_name" validate:"required,max=50"`
+	Address  string `json:"address" validate:"required,max=255"`
+	Email    string `json:"email" validate:"required,email,max=50"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
 
 type UpdateUserPayload struct {
-	FullName string   `json:"full_name" validate:"omitempty"`
-	Address  string   `json:"address" validate:"omitempty"`
+	FullName string   `json:"full_name" validate:"omitempty,max=50"`
+	Address  string   `json:"address" validate:"omitempty,max=255"`
 	UserRole UserRole `json:"user_role" validate:"omitempty"`
 }
+
+// ChangePasswordPayload changes a password. Users changing their own must
+// confirm the current one; admins may leave it out.
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"current_password" validate:"omitempty"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type PasswordResetPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type VerifyEmailPayload struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConfirmPasswordResetPayload struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type CredentialsPayload struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
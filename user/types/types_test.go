@@ -0,0 +1,35 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/4lerman/e_com/common/utils"
+)
+
+func TestPayloadsFitUserColumns(t *testing.T) {
+	long := strings.Repeat("a", 51)
+
+	tests := []struct {
+		name    string
+		payload any
+		valid   bool
+	}{
+		{"register", RegisterPayload{FullName: "Jane Doe", Address: "Almaty", Email: "jane@example.com", Password: "password1"}, true},
+		{"register with a long name", RegisterPayload{FullName: long, Address: "Almaty", Email: "jane@example.com", Password: "password1"}, false},
+		{"register with a long email", RegisterPayload{FullName: "Jane Doe", Address: "Almaty", Email: long + "@example.com", Password: "password1"}, false},
+		{"create with a long name", CreateUserPayload{FullName: long, Address: "Almaty", Email: "jane@example.com", UserRole: Client}, false},
+		{"create with a long email", CreateUserPayload{FullName: "Jane Doe", Address: "Almaty", Email: long + "@example.com", UserRole: Client}, false},
+		{"update with a long name", UpdateUserPayload{FullName: long}, false},
+		{"update without a name", UpdateUserPayload{Address: "Astana"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := utils.Validate.Struct(tt.payload)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("Validate() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}